@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dneto/sai-scout/internal/repository"
+)
+
+func runBackup(args []string) int {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: sai-scout backup <file>")
+		fmt.Fprintln(flags.Output(), "Writes a copy of the bolt database to file, or to the standard output if file is -.")
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	cfg := config{}
+	loadConfig(&cfg)
+	if cfg.Storage != storageBolt {
+		fmt.Fprintln(os.Stderr, "backup needs the bolt storage, MongoDB databases can be backed up with mongodump")
+		return 2
+	}
+
+	repo, closeRepo := cfg.openRepository(context.Background())
+	defer closeRepo()
+
+	out := flags.Arg(0)
+	if err := backup(repo.(*repository.Bolt), out); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to back up %s: %s\n", cfg.BoltPath, err)
+		return 1
+	}
+	if out != "-" {
+		fmt.Printf("backed up %s to %s\n", cfg.BoltPath, out)
+	}
+	return 0
+}
+
+// backup writes the database to a temporary file renamed to out once
+// complete, so an interrupted backup never replaces a good one.
+func backup(repo *repository.Bolt, out string) error {
+	if out == "-" {
+		_, err := repo.Backup(os.Stdout)
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(out), ".backup-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := repo.Backup(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), out)
+}
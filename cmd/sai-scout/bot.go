@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dneto/sai-scout/internal/assets"
+	"github.com/dneto/sai-scout/internal/cache"
+	"github.com/dneto/sai-scout/internal/commands"
+	"github.com/dneto/sai-scout/internal/i18n"
+	"github.com/dneto/sai-scout/internal/prefs"
+	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/dneto/sai-scout/internal/search"
+	"github.com/dneto/sai-scout/internal/syncer"
+	"github.com/dneto/sai-scout/pkg/discord"
+	"github.com/rs/zerolog/log"
+	"github.com/samber/mo"
+)
+
+type botConfig struct {
+	config
+	DiscordToken string `env:"DISCORD_TOKEN"`
+	// SyncInterval is how often bundles are synced. Zero disables the
+	// periodic sync.
+	SyncInterval time.Duration `env:"SYNC_INTERVAL" envDefault:"6h"`
+	// BundlesPath lists the bundle files loaded at startup by the in-memory
+	// storage, comma separated.
+	BundlesPath    string `env:"BUNDLES_PATH" envDefault:""`
+	BundlesVersion string `env:"BUNDLES_VERSION" envDefault:""`
+	// CacheSize is the number of cards, versions and guild configurations
+	// kept in memory. A zero CacheTTL disables the cache.
+	CacheSize int           `env:"CACHE_SIZE" envDefault:"5000"`
+	CacheTTL  time.Duration `env:"CACHE_TTL" envDefault:"10m"`
+	// AdminAddr is the address of the HTTP server of the cache stats, kept
+	// off the public server. An empty address disables it.
+	AdminAddr string `env:"ADMIN_ADDR" envDefault:"localhost:8081"`
+}
+
+func runBot() {
+	cfg := botConfig{}
+	loadConfig(&cfg)
+	if cfg.SyncInterval < 0 {
+		log.Fatal().Dur("interval", cfg.SyncInterval).Msg("SYNC_INTERVAL must not be negative, use 0 to disable the periodic sync")
+	}
+
+	ctx := context.Background()
+	repo, closeRepo := cfg.openRepository(ctx)
+	defer closeRepo()
+	if cfg.CacheTTL > 0 {
+		cached := cache.New(repo, cfg.CacheSize, cfg.CacheTTL)
+		serveAdmin(cfg.AdminAddr, cached)
+		defer func() {
+			log.Info().Interface("stats", cached.Stats()).Msg("cache stats")
+		}()
+		repo = cached
+	}
+
+	mirror := cfg.mirror()
+	names := search.NewCards(repo)
+	save, saveGlobals := saveFuncs(repo, false, false)
+	storage := syncer.Storage{
+		SaveBundle:  save,
+		SaveGlobals: saveGlobals,
+		FindBundle:  repo.FindBundle,
+		Quarantine:  repo.Quarantine,
+	}
+	if mirror != nil {
+		storage.SaveBundle = mirror.SaveFunc(storage.SaveBundle)
+		http.Handle(assets.Path, mirror.Handler())
+	}
+	if cfg.Storage == storageMemory {
+		loadBundles(ctx, cfg, storage)
+		names.Invalidate()
+	}
+
+	version := storedVersion(ctx, repo)
+	var cards repository.CardRepository = names
+	if mirror != nil {
+		cards = mirror.Cards(names)
+	}
+	session, err := setupBot(cfg.DiscordToken, repo, cards, names, version)
+
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to setup discord bot")
+	}
+
+	defer func() {
+		if err := session.Close(); err != nil {
+			log.Error().Err(err).Msg("Failed to close discord connection")
+		}
+	}()
+
+	syncCtx, stopSync := context.WithCancel(ctx)
+	defer stopSync()
+	// The search indexes are built again once per sync rather than after
+	// every bundle saved.
+	bundleSyncer := syncer.New(cfg.dataDragon(), version, storage, func(version string) {
+		if _, err := discord.UpdateStatus(0, statusMessage(version))(session); err != nil {
+			log.Error().Err(err).Msg("Failed to update status")
+		}
+	}).WithSavedFunc(names.Invalidate)
+	if cfg.SyncInterval > 0 {
+		go bundleSyncer.Run(syncCtx, cfg.SyncInterval)
+	} else {
+		log.Info().Msg("Periodic sync is disabled")
+	}
+
+	log.Info().Msg("Bot is now running.  Press CTRL-C to exit.")
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		_, err := w.Write([]byte(""))
+		if err != nil {
+			fmt.Println(err)
+		}
+	})
+	var srv http.Server
+	go func() {
+		err = http.ListenAndServe(":8080", nil)
+
+		if err != nil {
+			log.Fatal().Err(err).Msg("HTTP server failed")
+		}
+	}()
+
+	sc := make(chan os.Signal, 1)
+	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
+	<-sc
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Fatal().Err(err).Msg("failed to shutdown http server")
+	}
+}
+
+// serveAdmin serves the cache stats as JSON under /cache on addr, which should
+// not be reachable from outside the host, unless addr is empty.
+func serveAdmin(addr string, cached *cache.Repository) {
+	if addr == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cache", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(cached.Stats()); err != nil {
+			log.Error().Err(err).Msg("Failed to write cache stats")
+		}
+	})
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Error().Err(err).Str("addr", addr).Msg("Admin HTTP server failed")
+		}
+	}()
+}
+
+// loadBundles fills the in-memory storage at startup, from the files in
+// BUNDLES_PATH if it is set or else from the latest version published on
+// Data Dragon, or lorVersion if it can't be discovered.
+func loadBundles(ctx context.Context, cfg botConfig, storage syncer.Storage) {
+	if paths := splitList(cfg.BundlesPath); len(paths) > 0 {
+		version := cfg.BundlesVersion
+		if version == "" {
+			version = lorVersion
+		}
+
+		report, err := repository.ImportBundles(ctx, version, paths, storage.SaveBundle, storage.SaveGlobals, storage.Quarantine)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to import bundles")
+		}
+		log.Info().Int("imported", len(report.Imported)).Int("quarantined", len(report.Anomalies)).Msg("bundles loaded from disk")
+		return
+	}
+
+	dd := cfg.dataDragon()
+	latest, err := dd.LatestVersion(ctx, lorVersion)
+	if err != nil {
+		log.Warn().Err(err).Str("version", lorVersion).Msg("Failed to discover latest version, loading the known one")
+		latest = lorVersion
+	}
+	if err := syncer.New(dd, latest, storage, nil).SyncVersion(ctx, latest); err != nil {
+		log.Error().Err(err).Msg("Failed to load bundles from Data Dragon")
+	}
+}
+
+func statusMessage(version string) string {
+	return fmt.Sprintf("version %s", version)
+}
+
+// setupBot registers the commands. Cards are shown from cards, which can add
+// features like image mirroring on top of repo, and autocompleted from names.
+func setupBot(token string, repo repository.Repository, cards repository.CardRepository, names *search.Cards, version string) (*discordgo.Session, error) {
+	localizeFunc := i18n.LoadTranslations().Localize
+	resolve := prefs.NewResolver(repo).Resolve
+
+	return mo.TupleToResult(discord.NewSession(token, discordgo.IntentGuildMessages)).
+		Map(discord.Open).
+		Map(discord.UpdateStatus(0, statusMessage(version))).
+		Map(discord.OverwriteAndHandleCommands(
+			commands.Deck(repo, resolve, localizeFunc),
+			commands.Info(cards, names.SearchAllLocales, repo, resolve, localizeFunc),
+			commands.PatchNotes(repo, resolve, localizeFunc),
+			commands.InviteCommand,
+			commands.HelpCommand,
+			commands.Config(repo),
+			commands.Prefs(repo),
+			commands.Alias(repo, cards, names.SearchAllLocales, resolve),
+			commands.Search(names.Filter, resolve),
+			commands.DeckBuild(repo, cards, names.SearchAllLocales, resolve, localizeFunc),
+		)).Get()
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dneto/sai-scout/internal/repository"
+)
+
+func runImport(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: sai-scout import --version <version> [flags] <path>...")
+		flags.PrintDefaults()
+	}
+	version := flags.String("version", "", "version of the imported bundles, e.g. 4.10.0")
+	force := flags.Bool("force", false, "save bundles even if they are not newer than the stored ones")
+	dryRun := flags.Bool("dry-run", false, "print what would change without saving anything")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *version == "" || flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	cfg := config{}
+	loadConfig(&cfg)
+	if cfg.Storage == storageMemory {
+		fmt.Fprintln(os.Stderr, "the memory storage is loaded by the bot at startup, see BUNDLES_PATH")
+		return 2
+	}
+
+	ctx := context.Background()
+	repo, closeRepo := cfg.openRepository(ctx)
+	defer closeRepo()
+
+	save, saveGlobals := saveFuncs(repo, *force, *dryRun)
+	report, err := repository.ImportBundles(ctx, *version, flags.Args(), save, saveGlobals, quarantineFunc(repo, *dryRun))
+	fmt.Printf("imported %d bundles\n", len(report.Imported))
+	printAnomalies(report.Anomalies)
+
+	if mirror := cfg.mirror(); mirror != nil && !*dryRun {
+		images, imagesErr := mirror.Import(flags.Args())
+		fmt.Printf("imported %d card images\n", images)
+		err = errors.Join(err, imagesErr)
+	}
+
+	return printFailures(err)
+}
@@ -3,118 +3,160 @@ package main
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
-	"github.com/bwmarrin/discordgo"
 	"github.com/caarlos0/env/v9"
-	"github.com/dneto/sai-scout/internal/commands"
-	"github.com/dneto/sai-scout/internal/deck"
-	"github.com/dneto/sai-scout/internal/i18n"
+	"github.com/dneto/sai-scout/internal/assets"
 	"github.com/dneto/sai-scout/internal/repository"
-	"github.com/dneto/sai-scout/pkg/discord"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
-	"github.com/samber/mo"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 const lorVersion = "4.10.0"
 
+// Storage backends selected by STORAGE.
+const (
+	storageMongo  = "mongo"
+	storageMemory = "memory"
+	storageBolt   = "bolt"
+)
+
 type config struct {
-	DiscordToken string `env:"DISCORD_TOKEN"`
-	MongoURI     string `env:"MONGO_URI"`
+	Storage           string        `env:"STORAGE" envDefault:"mongo"`
+	MongoURI          string        `env:"MONGO_URI" envDefault:""`
+	BoltPath          string        `env:"BOLT_PATH" envDefault:"sai-scout.db"`
+	DataDragonURL     string        `env:"DATA_DRAGON_URL" envDefault:"https://dd.b.pvp.net"`
+	DataDragonTimeout time.Duration `env:"DATA_DRAGON_TIMEOUT" envDefault:"30s"`
+	DataDragonRetries int           `env:"DATA_DRAGON_RETRIES" envDefault:"3"`
+	AssetsDir         string        `env:"ASSETS_DIR" envDefault:""`
+	AssetsURL         string        `env:"ASSETS_URL" envDefault:""`
 }
 
-func main() {
-	cfg := config{}
-	err := env.ParseWithOptions(&cfg, env.Options{RequiredIfNoDef: true})
-	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to load env vars")
+func (c config) dataDragon() *repository.DataDragon {
+	return repository.NewDataDragon(c.DataDragonURL, nil).
+		WithTimeout(c.DataDragonTimeout).
+		WithRetries(c.DataDragonRetries, time.Second)
+}
+
+// mirror returns the card image mirror, or nil if ASSETS_DIR is not set.
+func (c config) mirror() *assets.Mirror {
+	if c.AssetsDir == "" {
+		return nil
 	}
+	return assets.NewMirror(c.AssetsDir, c.AssetsURL, nil)
+}
 
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+const usage = `Usage: sai-scout [command] [flags]
 
-	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
-	opts := options.Client().ApplyURI(cfg.MongoURI).SetServerAPIOptions(serverAPI)
+Commands:
+  bot    Run the discord bot (default)
+  sync   Download bundles from Data Dragon into the database
+  import Import bundle files or zip archives from disk into the database
+  backup Write a copy of the bolt database
+`
 
-	ctx := context.Background()
-	cli, err := mongo.Connect(ctx, opts)
-	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to connect to mongo")
-	}
-	defer func() {
-		if err := cli.Disconnect(ctx); err != nil {
-			log.Error().Err(err).Msg("Failed to close mongo connection")
-		}
-	}()
+func main() {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 
-	// err = repository.UpdateSetBundles(ctx, lorVersion, repository.InsertBuilder(cli))
-	// if err != nil {
-	// 	log.Fatal().Err(err).Msg("Failed to retrieve set bundles")
-	// }
+	command, args := "bot", os.Args[1:]
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
 
-	session, err := setupBot(cfg.DiscordToken, cli)
+	switch command {
+	case "bot":
+		runBot()
+	case "sync":
+		os.Exit(runSync(args))
+	case "import":
+		os.Exit(runImport(args))
+	case "backup":
+		os.Exit(runBackup(args))
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
+}
 
+func loadConfig(cfg any) {
+	err := env.ParseWithOptions(cfg, env.Options{RequiredIfNoDef: true})
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to setup discord bot")
+		log.Fatal().Err(err).Msg("Failed to load env vars")
 	}
+}
 
-	defer func() {
-		if err := session.Close(); err != nil {
-			log.Error().Err(err).Msg("Failed to close discord connection")
+// openRepository opens the storage selected by STORAGE, upgrading what
+// previous versions of the bot stored. The returned function closes it.
+func (c config) openRepository(ctx context.Context) (repository.Repository, func()) {
+	repo, closeRepo := c.connectRepository(ctx)
+	if m, ok := repo.(repository.Migrator); ok {
+		if err := m.Migrate(ctx); err != nil {
+			closeRepo()
+			log.Fatal().Err(err).Msg("Failed to migrate storage")
 		}
-	}()
+	}
+	return repo, closeRepo
+}
 
-	log.Info().Msg("Bot is now running.  Press CTRL-C to exit.")
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(200)
-		_, err := w.Write([]byte(""))
-		if err != nil {
-			fmt.Println(err)
+func (c config) connectRepository(ctx context.Context) (repository.Repository, func()) {
+	switch c.Storage {
+	case storageMongo:
+		if c.MongoURI == "" {
+			log.Fatal().Msg("MONGO_URI is required by the mongo storage")
 		}
-	})
-	var srv http.Server
-	go func() {
-		err = http.ListenAndServe(":8080", nil)
-
+		cli := connectMongo(ctx, c.MongoURI)
+		return repository.NewMongo(cli.Database(repository.DefaultDatabase)), func() {
+			disconnectMongo(ctx, cli)
+		}
+	case storageMemory:
+		return repository.NewMemory(), func() {}
+	case storageBolt:
+		repo, err := repository.OpenBolt(c.BoltPath)
 		if err != nil {
-			log.Fatal().Err(err).Msg("HTTP server failed")
+			log.Fatal().Err(err).Msg("Failed to open bolt database")
+		}
+		return repo, func() {
+			if err := repo.Close(); err != nil {
+				log.Error().Err(err).Msg("Failed to close bolt database")
+			}
 		}
-	}()
+	}
+
+	log.Fatal().Str("storage", c.Storage).Msg("Unknown storage, use mongo, memory or bolt")
+	return nil, nil
+}
 
-	sc := make(chan os.Signal, 1)
-	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
-	<-sc
+func connectMongo(ctx context.Context, uri string) *mongo.Client {
+	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
+	opts := options.Client().ApplyURI(uri).SetServerAPIOptions(serverAPI)
+
+	cli, err := mongo.Connect(ctx, opts)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to connect to mongo")
+	}
+	return cli
+}
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	if err := srv.Shutdown(shutdownCtx); err != nil {
-		log.Fatal().Err(err).Msg("failed to shutdown http server")
+func disconnectMongo(ctx context.Context, cli *mongo.Client) {
+	if err := cli.Disconnect(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to close mongo connection")
 	}
 }
 
-func setupBot(token string, cli *mongo.Client) (*discordgo.Session, error) {
-	findCards := repository.FindCardsBuilder(cli)
-	searchByName := repository.SearchByNameBuilder(cli)
-
-	decode := deck.BuildLoadDeckInfo(findCards)
-	localizeFunc := i18n.LoadTranslations().Localize
-	getLang := repository.GetLang(cli)
-	getTemplate := repository.GetTemplate(cli)
-
-	return mo.TupleToResult(discord.NewSession(token, discordgo.IntentGuildMessages)).
-		Map(discord.Open).
-		Map(discord.UpdateStatus(0, fmt.Sprintf("version %s", lorVersion))).
-		Map(discord.OverwriteAndHandleCommands(
-			commands.Deck(decode, localizeFunc, getLang, getTemplate),
-			commands.Info(findCards, searchByName, localizeFunc, getLang),
-			commands.InviteCommand,
-			commands.HelpCommand,
-			commands.Config(repository.SaveLang(cli), repository.SaveURLTemplate(cli)),
-		)).Get()
+// storedVersion returns the latest version saved in the database, falling
+// back to lorVersion.
+func storedVersion(ctx context.Context, repo repository.BundleRepository) string {
+	version, err := repo.StoredVersion(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to retrieve stored version")
+	}
+	if version == "" {
+		version = lorVersion
+	}
+	return version
 }
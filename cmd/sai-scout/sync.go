@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/dneto/sai-scout/internal/i18n"
+	"github.com/dneto/sai-scout/internal/repository"
+)
+
+// syncOptions are the flags of sai-scout sync.
+type syncOptions struct {
+	version string
+	sets    []string
+	locales []string
+	force   bool
+	dryRun  bool
+}
+
+// errUnknownLocale is returned by parseSyncFlags for locales the bot does not
+// support.
+var errUnknownLocale = errors.New("unknown locale")
+
+// parseSyncFlags parses the flags of sai-scout sync. Sets and locales are
+// lower cased, like Data Dragon names them. Flag errors are printed by the
+// flag package.
+func parseSyncFlags(args []string) (syncOptions, error) {
+	flags := flag.NewFlagSet("sync", flag.ContinueOnError)
+	version := flags.String("version", "", "bundle version to sync, e.g. 4.10.0 (default: latest published)")
+	setList := flags.String("sets", "", "comma separated sets to sync, e.g. set1,set2 (default: all)")
+	localeList := flags.String("locales", "", "comma separated locales to sync, e.g. en_us,pt_br (default: all)")
+	force := flags.Bool("force", false, "save bundles even if they are not newer than the stored ones")
+	dryRun := flags.Bool("dry-run", false, "print what would change without saving anything")
+	if err := flags.Parse(args); err != nil {
+		return syncOptions{}, err
+	}
+
+	opts := syncOptions{
+		version: *version,
+		sets:    splitList(strings.ToLower(*setList)),
+		locales: splitList(strings.ToLower(*localeList)),
+		force:   *force,
+		dryRun:  *dryRun,
+	}
+	for _, l := range opts.locales {
+		if !slices.Contains(i18n.Locales, i18n.Locale(l)) {
+			return syncOptions{}, fmt.Errorf("%w %q", errUnknownLocale, l)
+		}
+	}
+	return opts, nil
+}
+
+func runSync(args []string) int {
+	opts, err := parseSyncFlags(args)
+	if errors.Is(err, errUnknownLocale) {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if err != nil {
+		return 2
+	}
+
+	cfg := config{}
+	loadConfig(&cfg)
+	if cfg.Storage == storageMemory {
+		fmt.Fprintln(os.Stderr, "the memory storage is loaded by the bot at startup, see BUNDLES_PATH")
+		return 2
+	}
+
+	ctx := context.Background()
+	repo, closeRepo := cfg.openRepository(ctx)
+	defer closeRepo()
+
+	dd := cfg.dataDragon()
+	if opts.version == "" {
+		latest, err := dd.LatestVersion(ctx, storedVersion(ctx, repo))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to discover latest version: %s\n", err)
+			return 1
+		}
+		opts.version = latest
+	}
+
+	save, saveGlobals := saveFuncs(repo, opts.force, opts.dryRun)
+	if mirror := cfg.mirror(); mirror != nil && !opts.dryRun {
+		save = mirror.SaveFunc(save)
+	}
+	params := repository.UpdateParams{
+		Version:    opts.version,
+		Sets:       opts.sets,
+		Locales:    opts.locales,
+		FindBundle: repo.FindBundle,
+		Force:      opts.force,
+		Quarantine: quarantineFunc(repo, opts.dryRun),
+	}
+	fmt.Printf("syncing version %s\n", params.Version)
+
+	var errs []error
+	if err := dd.UpdateGlobals(ctx, params, saveGlobals); err != nil {
+		errs = append(errs, err)
+	}
+
+	report, err := dd.UpdateSetBundles(ctx, params, save)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if report != nil {
+		fmt.Printf("%d bundles updated, %d unchanged, %d failed\n",
+			report.Count(repository.OutcomeUpdated),
+			report.Count(repository.OutcomeUnchanged),
+			report.Count(repository.OutcomeFailed))
+		if len(report.Found) > 0 {
+			fmt.Printf("new sets: %s\n", strings.Join(report.Found, ", "))
+		}
+		if len(report.Skipped) > 0 {
+			fmt.Printf("sets without bundles: %s\n", strings.Join(report.Skipped, ", "))
+		}
+		if len(report.Missing) > 0 {
+			fmt.Printf("bundles missing for some locales: %s\n", strings.Join(report.Missing, ", "))
+		}
+		printAnomalies(report.Anomalies)
+	}
+
+	return printFailures(errors.Join(errs...))
+}
+
+// saveFuncs returns the functions used to save downloaded or imported
+// bundles according to the --force and --dry-run flags.
+func saveFuncs(repo repository.BundleRepository, force bool, dryRun bool) (func(context.Context, *repository.SetBundle) error, func(context.Context, *repository.Globals) error) {
+	if dryRun {
+		printer := &changePrinter{force: force}
+		return printer.bundles(repo.FindBundle), printer.globals(repo.FindGlobals)
+	}
+
+	save := func(ctx context.Context, b *repository.SetBundle) error {
+		return repo.SaveBundle(ctx, b, force)
+	}
+	saveGlobals := func(ctx context.Context, g *repository.Globals) error {
+		return repo.SaveGlobals(ctx, g, force)
+	}
+	return save, saveGlobals
+}
+
+// quarantineFunc returns the function used to save invalid cards, or nil on
+// dry runs.
+func quarantineFunc(repo repository.BundleRepository, dryRun bool) func(context.Context, []repository.Anomaly) error {
+	if dryRun {
+		return nil
+	}
+	return repo.Quarantine
+}
+
+// printAnomalies prints the cards left out because they failed validation.
+func printAnomalies(anomalies []repository.Anomaly) {
+	if len(anomalies) == 0 {
+		return
+	}
+
+	fmt.Printf("%d invalid cards quarantined:\n", len(anomalies))
+	for _, a := range anomalies {
+		fmt.Printf("  %s-%s %s: %s\n", a.Set, a.Locale, a.CardCode, strings.Join(a.Reasons, ", "))
+	}
+}
+
+// printFailures prints a summary of the bundles that failed and returns the
+// process exit code.
+func printFailures(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	failures := bundleErrors(err)
+	fmt.Fprintf(os.Stderr, "%d bundles failed:\n", len(failures))
+	for _, err := range failures {
+		fmt.Fprintf(os.Stderr, "  %s\n", err)
+	}
+	return 1
+}
+
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// bundleErrors flattens the errors joined by the download pools, keeping the
+// bundle name each one is wrapped with.
+func bundleErrors(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var errs []error
+		for _, e := range joined.Unwrap() {
+			errs = append(errs, bundleErrors(e)...)
+		}
+		return errs
+	}
+
+	if inner := errors.Unwrap(err); inner != nil {
+		if _, ok := inner.(interface{ Unwrap() []error }); ok {
+			return bundleErrors(inner)
+		}
+	}
+
+	return []error{err}
+}
+
+// changePrinter replaces the save functions on dry runs, printing what would
+// be saved instead.
+type changePrinter struct {
+	mu    sync.Mutex
+	force bool
+}
+
+func (p *changePrinter) bundles(findBundle func(context.Context, string, string) (*repository.SetBundle, error)) func(context.Context, *repository.SetBundle) error {
+	return func(ctx context.Context, b *repository.SetBundle) error {
+		stored, err := findBundle(ctx, b.Set, b.Locale)
+		if err != nil {
+			return err
+		}
+
+		name := fmt.Sprintf("%s-%s", b.Set, b.Locale)
+		switch {
+		case stored == nil:
+			p.print("%s: new, %d cards (%s)", name, len(b.Cards), b.Version)
+		case p.force || b.LastModified.After(stored.LastModified):
+			p.print("%s: update, %d cards (%s -> %s)", name, len(b.Cards), stored.Version, b.Version)
+		default:
+			p.print("%s: unchanged", name)
+		}
+		return nil
+	}
+}
+
+func (p *changePrinter) globals(findGlobals func(context.Context, string) (*repository.Globals, error)) func(context.Context, *repository.Globals) error {
+	return func(ctx context.Context, g *repository.Globals) error {
+		stored, err := findGlobals(ctx, g.Locale)
+		if err != nil {
+			return err
+		}
+
+		name := fmt.Sprintf("core-%s", g.Locale)
+		switch {
+		case stored == nil:
+			p.print("%s: new (%s)", name, g.Version)
+		case p.force || g.Version != stored.Version || g.LastModified.After(stored.LastModified):
+			p.print("%s: update (%s -> %s)", name, stored.Version, g.Version)
+		default:
+			p.print("%s: unchanged", name)
+		}
+		return nil
+	}
+}
+
+func (p *changePrinter) print(format string, args ...any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Printf(format+"\n", args...)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("parseSyncFlags", func() {
+	DescribeTable("parses the flags",
+		func(args []string, expected syncOptions) {
+			Expect(parseSyncFlags(args)).To(Equal(expected))
+		},
+		Entry("without flags", nil, syncOptions{}),
+		Entry("version", []string{"--version", "4.10.0"}, syncOptions{version: "4.10.0"}),
+		Entry("sets", []string{"--sets", "set1, set2,"}, syncOptions{sets: []string{"set1", "set2"}}),
+		Entry("sets in upper case", []string{"--sets", "Set1,SET6cde"}, syncOptions{sets: []string{"set1", "set6cde"}}),
+		Entry("locales", []string{"--locales", "en_us,PT_BR"}, syncOptions{locales: []string{"en_us", "pt_br"}}),
+		Entry("force and dry run", []string{"--force", "--dry-run"}, syncOptions{force: true, dryRun: true}),
+	)
+
+	It("rejects unknown locales", func() {
+		_, err := parseSyncFlags([]string{"--locales", "en_us,xx_xx"})
+		Expect(err).To(MatchError(errUnknownLocale))
+		Expect(err).To(MatchError(`unknown locale "xx_xx"`))
+	})
+
+	It("rejects unknown flags", func() {
+		_, err := parseSyncFlags([]string{"--unknown"})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -15,7 +15,9 @@ require (
 	github.com/samber/lo v1.37.0
 	github.com/samber/mo v1.8.0
 	github.com/sourcegraph/conc v0.3.0
+	go.etcd.io/bbolt v1.3.8
 	go.mongodb.org/mongo-driver v1.12.1
+	golang.org/x/sync v0.2.0
 	golang.org/x/text v0.13.0
 )
 
@@ -45,7 +47,6 @@ require (
 	golang.org/x/crypto v0.13.0 // indirect
 	golang.org/x/exp v0.0.0-20220303212507-bbda1eaf7a17 // indirect
 	golang.org/x/net v0.10.0 // indirect
-	golang.org/x/sync v0.2.0 // indirect
 	golang.org/x/sys v0.12.0 // indirect
 	golang.org/x/tools v0.7.0 // indirect
 	google.golang.org/protobuf v1.31.0 // indirect
@@ -0,0 +1,284 @@
+package assets
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/rs/zerolog/log"
+	"github.com/sourcegraph/conc/pool"
+)
+
+// Path is the HTTP path the mirrored images are served from.
+const Path = "/assets/"
+
+// cacheControl lets clients and proxies keep images for a week. Card art
+// rarely changes between patches.
+const cacheControl = "public, max-age=604800"
+
+// cardImage matches Data Dragon card image URLs and bundle archive entries,
+// like "en_us/img/cards/01DE001-full.png".
+var cardImage = regexp.MustCompile(`(?:^|/)([a-z]{2}_[a-z]{2})/img/cards/([0-9A-Za-z-]+\.png)$`)
+
+// Mirror keeps a local copy of card images and serves them over HTTP.
+type Mirror struct {
+	dir     string
+	baseURL string
+	client  *http.Client
+}
+
+// NewMirror creates a mirror storing images in dir. baseURL is the public URL
+// of the HTTP server serving Handler; when it is empty, cards keep linking
+// to Data Dragon.
+func NewMirror(dir string, baseURL string, client *http.Client) *Mirror {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Mirror{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/"), client: client}
+}
+
+// key returns the path of an image inside the mirror, "<locale>/<file>".
+func key(name string) (string, bool) {
+	m := cardImage.FindStringSubmatch(name)
+	if m == nil {
+		return "", false
+	}
+	return m[1] + "/" + m[2], true
+}
+
+func (m *Mirror) file(key string) string {
+	return filepath.Join(m.dir, filepath.FromSlash(key))
+}
+
+func (m *Mirror) has(key string) bool {
+	_, err := os.Stat(m.file(key))
+	return err == nil
+}
+
+// Download saves the images of the bundle cards that are not mirrored yet.
+func (m *Mirror) Download(ctx context.Context, bundle *repository.SetBundle) error {
+	p := pool.New().WithErrors().WithMaxGoroutines(10)
+	for _, c := range bundle.Cards {
+		for _, a := range c.Assets {
+			for _, u := range []string{a.GameAbsolutePath, a.FullAbsolutePath} {
+				u := u
+				p.Go(func() error {
+					return m.download(ctx, u)
+				})
+			}
+		}
+	}
+	return p.Wait()
+}
+
+func (m *Mirror) download(ctx context.Context, imageURL string) error {
+	parsed, err := url.Parse(imageURL)
+	if err != nil {
+		return err
+	}
+
+	k, ok := key(parsed.Path)
+	if !ok || m.has(k) {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", k, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: status code %d", k, resp.StatusCode)
+	}
+
+	if err := m.write(k, resp.Body); err != nil {
+		return fmt.Errorf("%s: %w", k, err)
+	}
+	return nil
+}
+
+// write saves the image through a temporary file, so a partial download is
+// never served.
+func (m *Mirror) write(key string, r io.Reader) error {
+	name := m.file(key)
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".download-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// SaveFunc wraps a bundle save function so the images of every saved bundle
+// are mirrored. Images that fail to download are logged and keep linking to
+// Data Dragon.
+func (m *Mirror) SaveFunc(save func(context.Context, *repository.SetBundle) error) func(context.Context, *repository.SetBundle) error {
+	return func(ctx context.Context, bundle *repository.SetBundle) error {
+		if err := save(ctx, bundle); err != nil {
+			return err
+		}
+
+		if err := m.Download(ctx, bundle); err != nil {
+			log.Error().Err(err).Str("set", bundle.Set).Str("locale", bundle.Locale).Msg("failed to mirror card images")
+		}
+		return nil
+	}
+}
+
+// Import copies card images from disk into the mirror. Paths can be the
+// official bundle zip archives, extracted bundles or directories containing
+// them. It returns the number of imported images.
+func (m *Mirror) Import(paths []string) (int, error) {
+	imported := 0
+	var errs []error
+	for _, p := range paths {
+		err := filepath.WalkDir(p, func(name string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+
+			if strings.EqualFold(filepath.Ext(name), ".zip") {
+				n, err := m.importZip(name)
+				imported += n
+				if err != nil {
+					errs = append(errs, err)
+				}
+				return nil
+			}
+
+			if k, ok := key(filepath.ToSlash(name)); ok {
+				if err := m.importFile(k, func() (io.ReadCloser, error) { return os.Open(name) }); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", name, err))
+					return nil
+				}
+				imported++
+			}
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return imported, errors.Join(errs...)
+}
+
+func (m *Mirror) importZip(name string) (int, error) {
+	archive, err := zip.OpenReader(name)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	defer archive.Close()
+
+	imported := 0
+	var errs []error
+	for _, f := range archive.File {
+		k, ok := key(path.Clean(f.Name))
+		if !ok {
+			continue
+		}
+		if err := m.importFile(k, f.Open); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", name, f.Name, err))
+			continue
+		}
+		imported++
+	}
+	return imported, errors.Join(errs...)
+}
+
+func (m *Mirror) importFile(key string, open func() (io.ReadCloser, error)) error {
+	r, err := open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return m.write(key, r)
+}
+
+// Handler serves the mirrored images under Path.
+func (m *Mirror) Handler() http.Handler {
+	files := http.StripPrefix(strings.TrimSuffix(Path, "/"), http.FileServer(http.Dir(m.dir)))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Cache-Control", cacheControl)
+		files.ServeHTTP(w, r)
+	})
+}
+
+// URL returns the mirror URL of a Data Dragon image URL, or the same URL if
+// the image is not mirrored.
+func (m *Mirror) URL(imageURL string) string {
+	if m.baseURL == "" {
+		return imageURL
+	}
+
+	parsed, err := url.Parse(imageURL)
+	if err != nil {
+		return imageURL
+	}
+
+	k, ok := key(parsed.Path)
+	if !ok || !m.has(k) {
+		return imageURL
+	}
+	return m.baseURL + Path + k
+}
+
+// Cards wraps a card repository so the images of the cards found point to the
+// mirror.
+func (m *Mirror) Cards(repo repository.CardRepository) repository.CardRepository {
+	return mirroredCards{CardRepository: repo, mirror: m}
+}
+
+type mirroredCards struct {
+	repository.CardRepository
+	mirror *Mirror
+}
+
+func (r mirroredCards) FindCards(ctx context.Context, language string, version string, codes ...string) ([]*repository.Card, error) {
+	cards, err := r.CardRepository.FindCards(ctx, language, version, codes...)
+	for i, c := range cards {
+		mirrored := *c
+		mirrored.Assets = append(mirrored.Assets[:0:0], c.Assets...)
+		for j, a := range mirrored.Assets {
+			mirrored.Assets[j].GameAbsolutePath = r.mirror.URL(a.GameAbsolutePath)
+			mirrored.Assets[j].FullAbsolutePath = r.mirror.URL(a.FullAbsolutePath)
+		}
+		cards[i] = &mirrored
+	}
+	return cards, err
+}
@@ -0,0 +1,178 @@
+package assets_test
+
+import (
+	"archive/zip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dneto/sai-scout/internal/assets"
+	"github.com/dneto/sai-scout/internal/repository"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestAssets(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Assets Suite")
+}
+
+var _ = Describe("Mirror", func() {
+	var (
+		ctx      = context.Background()
+		cdn      *httptest.Server
+		requests atomic.Int32
+		dir      string
+		mirror   *assets.Mirror
+	)
+
+	BeforeEach(func() {
+		requests.Store(0)
+		cdn = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			if filepath.Base(r.URL.Path) == "missing.png" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte("image " + r.URL.Path))
+		}))
+		DeferCleanup(cdn.Close)
+
+		dir = GinkgoT().TempDir()
+		mirror = assets.NewMirror(dir, "https://scout.example.com/", cdn.Client())
+	})
+
+	bundle := func(codes ...string) *repository.SetBundle {
+		b := &repository.SetBundle{Set: "set1", Locale: "en_us"}
+		for _, code := range codes {
+			c := &repository.Card{CardCode: code}
+			c.Assets = append(c.Assets, struct {
+				GameAbsolutePath string `json:"gameAbsolutePath"`
+				FullAbsolutePath string `json:"fullAbsolutePath"`
+			}{
+				GameAbsolutePath: cdn.URL + "/4_10_0/set1/en_us/img/cards/" + code + ".png",
+				FullAbsolutePath: cdn.URL + "/4_10_0/set1/en_us/img/cards/" + code + "-full.png",
+			})
+			b.Cards = append(b.Cards, c)
+		}
+		return b
+	}
+
+	Context("Download", func() {
+		It("saves card images by locale", func() {
+			Expect(mirror.Download(ctx, bundle("01DE001"))).To(Succeed())
+
+			Expect(os.ReadFile(filepath.Join(dir, "en_us", "01DE001.png"))).
+				To(BeEquivalentTo("image /4_10_0/set1/en_us/img/cards/01DE001.png"))
+			Expect(filepath.Join(dir, "en_us", "01DE001-full.png")).To(BeAnExistingFile())
+		})
+
+		It("skips images already mirrored", func() {
+			Expect(mirror.Download(ctx, bundle("01DE001"))).To(Succeed())
+			Expect(mirror.Download(ctx, bundle("01DE001"))).To(Succeed())
+			Expect(requests.Load()).To(BeEquivalentTo(2))
+		})
+
+		It("fails on missing images", func() {
+			err := mirror.Download(ctx, bundle("missing"))
+			Expect(err).To(MatchError(ContainSubstring("en_us/missing.png: status code 404")))
+		})
+	})
+
+	Context("SaveFunc", func() {
+		It("saves the bundle even if images fail", func() {
+			saved := 0
+			save := mirror.SaveFunc(func(context.Context, *repository.SetBundle) error {
+				saved++
+				return nil
+			})
+			Expect(save(ctx, bundle("missing", "01DE001"))).To(Succeed())
+			Expect(saved).To(Equal(1))
+			Expect(filepath.Join(dir, "en_us", "01DE001.png")).To(BeAnExistingFile())
+		})
+	})
+
+	Context("Cards", func() {
+		It("points mirrored images to the mirror", func() {
+			b := bundle("01DE001", "01DE002")
+			Expect(mirror.Download(ctx, bundle("01DE001"))).To(Succeed())
+
+			cards, err := mirror.Cards(fakeCards(b.Cards)).FindCards(ctx, "en_us", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cards[0].Assets[0].GameAbsolutePath).To(Equal("https://scout.example.com/assets/en_us/01DE001.png"))
+			Expect(cards[0].Assets[0].FullAbsolutePath).To(Equal("https://scout.example.com/assets/en_us/01DE001-full.png"))
+			Expect(cards[1].Assets[0].GameAbsolutePath).To(HavePrefix(cdn.URL))
+		})
+
+		It("keeps Data Dragon URLs without a public URL", func() {
+			mirror = assets.NewMirror(dir, "", cdn.Client())
+			Expect(mirror.Download(ctx, bundle("01DE001"))).To(Succeed())
+			Expect(mirror.URL(cdn.URL + "/4_10_0/set1/en_us/img/cards/01DE001.png")).To(HavePrefix(cdn.URL))
+		})
+	})
+
+	Context("Import", func() {
+		It("imports images from bundle archives and directories", func() {
+			src := GinkgoT().TempDir()
+			archive := filepath.Join(src, "set1-lite-en_us.zip")
+			f, err := os.Create(archive)
+			Expect(err).ToNot(HaveOccurred())
+			zw := zip.NewWriter(f)
+			for _, name := range []string{"en_us/img/cards/01DE001.png", "en_us/data/set1-en_us.json"} {
+				w, err := zw.Create(name)
+				Expect(err).ToNot(HaveOccurred())
+				_, _ = w.Write([]byte(name))
+			}
+			Expect(zw.Close()).To(Succeed())
+			Expect(f.Close()).To(Succeed())
+
+			extracted := filepath.Join(src, "set2", "pt_br", "img", "cards")
+			Expect(os.MkdirAll(extracted, 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(extracted, "02NX001.png"), []byte("png"), 0o644)).To(Succeed())
+
+			imported, err := mirror.Import([]string{src})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(imported).To(Equal(2))
+			Expect(filepath.Join(dir, "en_us", "01DE001.png")).To(BeAnExistingFile())
+			Expect(filepath.Join(dir, "pt_br", "02NX001.png")).To(BeAnExistingFile())
+		})
+	})
+
+	Context("Handler", func() {
+		It("serves images with cache headers", func() {
+			Expect(mirror.Download(ctx, bundle("01DE001"))).To(Succeed())
+
+			rec := httptest.NewRecorder()
+			mirror.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/assets/en_us/01DE001.png", nil))
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Header().Get("Cache-Control")).To(Equal("public, max-age=604800"))
+			Expect(rec.Header().Get("Last-Modified")).ToNot(BeEmpty())
+		})
+
+		It("does not list directories", func() {
+			Expect(mirror.Download(ctx, bundle("01DE001"))).To(Succeed())
+
+			rec := httptest.NewRecorder()
+			mirror.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/assets/en_us/", nil))
+			Expect(rec.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+})
+
+type fakeCards []*repository.Card
+
+func (f fakeCards) FindCards(context.Context, string, string, ...string) ([]*repository.Card, error) {
+	return f, nil
+}
+
+func (f fakeCards) SearchByName(context.Context, string, string, string) ([]*repository.Card, error) {
+	return f, nil
+}
+
+func (f fakeCards) FindSnapshot(context.Context, string, string) ([]*repository.Card, error) {
+	return f, nil
+}
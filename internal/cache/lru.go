@@ -0,0 +1,120 @@
+// Package cache keeps recently read cards and guild configurations in memory,
+// so the commands don't read the database on every interaction.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Counters are the number of reads found in a cache and the number of reads
+// that had to go to the database.
+type Counters struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	Size   int    `json:"size"`
+}
+
+// LRU keeps up to size values, dropping the least recently used one when it
+// is full. Values are dropped when they are older than ttl. It is safe for
+// concurrent use.
+type LRU[K comparable, V any] struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu       sync.Mutex
+	items    map[K]*list.Element
+	order    *list.List
+	counters Counters
+}
+
+type item[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+func NewLRU[K comparable, V any](size int, ttl time.Duration) *LRU[K, V] {
+	return &LRU[K, V]{
+		size:  size,
+		ttl:   ttl,
+		now:   time.Now,
+		items: make(map[K]*list.Element),
+		order: list.New(),
+	}
+}
+
+// Get returns the value of key, if it was set and has not expired.
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		it := e.Value.(*item[K, V])
+		if c.now().Before(it.expires) {
+			c.order.MoveToFront(e)
+			c.counters.Hits++
+			return it.value, true
+		}
+		c.remove(e)
+	}
+
+	c.counters.Misses++
+	var zero V
+	return zero, false
+}
+
+// Set sets the value of key, dropping the least recently used value if the
+// cache is full.
+func (c *LRU[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := c.now().Add(c.ttl)
+	if e, ok := c.items[key]; ok {
+		e.Value = &item[K, V]{key: key, value: value, expires: expires}
+		c.order.MoveToFront(e)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&item[K, V]{key: key, value: value, expires: expires})
+	if c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// Remove drops the value of key.
+func (c *LRU[K, V]) Remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.remove(e)
+	}
+}
+
+// Purge drops every value. The counters are kept.
+func (c *LRU[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[K]*list.Element)
+	c.order.Init()
+}
+
+// Counters returns the hits and misses since the cache was created.
+func (c *LRU[K, V]) Counters() Counters {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counters := c.counters
+	counters.Size = c.order.Len()
+	return counters
+}
+
+func (c *LRU[K, V]) remove(e *list.Element) {
+	c.order.Remove(e)
+	delete(c.items, e.Value.(*item[K, V]).key)
+}
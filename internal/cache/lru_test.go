@@ -0,0 +1,64 @@
+package cache_test
+
+import (
+	"time"
+
+	"github.com/dneto/sai-scout/internal/cache"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LRU", func() {
+	It("returns the values set", func() {
+		c := cache.NewLRU[string, int](2, time.Minute)
+		c.Set("a", 1)
+
+		v, ok := c.Get("a")
+		Expect(ok).To(BeTrue())
+		Expect(v).To(Equal(1))
+
+		_, ok = c.Get("b")
+		Expect(ok).To(BeFalse())
+		Expect(c.Counters()).To(Equal(cache.Counters{Hits: 1, Misses: 1, Size: 1}))
+	})
+
+	It("drops the least recently used value when full", func() {
+		c := cache.NewLRU[string, int](2, time.Minute)
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Get("a")
+		c.Set("c", 3)
+
+		_, ok := c.Get("b")
+		Expect(ok).To(BeFalse())
+		_, ok = c.Get("a")
+		Expect(ok).To(BeTrue())
+		_, ok = c.Get("c")
+		Expect(ok).To(BeTrue())
+	})
+
+	It("drops expired values", func() {
+		c := cache.NewLRU[string, int](2, 10*time.Millisecond)
+		c.Set("a", 1)
+
+		Eventually(func() bool {
+			_, ok := c.Get("a")
+			return ok
+		}).Should(BeFalse())
+		Expect(c.Counters().Size).To(BeZero())
+	})
+
+	It("removes and purges values", func() {
+		c := cache.NewLRU[string, int](2, time.Minute)
+		c.Set("a", 1)
+		c.Set("b", 2)
+
+		c.Remove("a")
+		_, ok := c.Get("a")
+		Expect(ok).To(BeFalse())
+
+		c.Purge()
+		_, ok = c.Get("b")
+		Expect(ok).To(BeFalse())
+	})
+})
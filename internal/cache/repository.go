@@ -0,0 +1,179 @@
+package cache
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/samber/lo"
+)
+
+type cardKey struct {
+	language, version, code string
+}
+
+// Stats are the counters of each cache of a Repository.
+type Stats struct {
+	Cards    Counters `json:"cards"`
+	Versions Counters `json:"versions"`
+	Guilds   Counters `json:"guilds"`
+	Users    Counters `json:"users"`
+}
+
+// Repository wraps a repository, keeping the cards, current versions and
+// guild configurations read. Names are not kept: the bot searches them with
+// the indexes of search.Cards. Cards are dropped when a bundle is saved
+// through it, and a guild configuration when it is saved through it. Changes
+// made by other processes, like sai-scout sync, are seen once the values
+// expire.
+type Repository struct {
+	repository.Repository
+
+	cards    *LRU[cardKey, *repository.Card]
+	versions *LRU[string, string]
+	guilds   *LRU[string, repository.GuildConfig]
+	users    *LRU[string, repository.Preferences]
+}
+
+var _ repository.Repository = (*Repository)(nil)
+
+// New wraps repo, keeping up to size values of each kind for ttl.
+func New(repo repository.Repository, size int, ttl time.Duration) *Repository {
+	return &Repository{
+		Repository: repo,
+		cards:      NewLRU[cardKey, *repository.Card](size, ttl),
+		versions:   NewLRU[string, string](size, ttl),
+		guilds:     NewLRU[string, repository.GuildConfig](size, ttl),
+		users:      NewLRU[string, repository.Preferences](size, ttl),
+	}
+}
+
+// FindCards finds the cards not kept yet in the wrapped repository.
+func (r *Repository) FindCards(ctx context.Context, language string, version string, codes ...string) ([]*repository.Card, error) {
+	var cards []*repository.Card
+	var missing []string
+	for _, code := range lo.Uniq(codes) {
+		if c, ok := r.cards.Get(cardKey{language: language, version: version, code: code}); ok {
+			cards = append(cards, c)
+		} else {
+			missing = append(missing, code)
+		}
+	}
+
+	if len(missing) > 0 {
+		found, err := r.Repository.FindCards(ctx, language, version, missing...)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range found {
+			r.cards.Set(cardKey{language: language, version: version, code: c.CardCode}, c)
+		}
+		cards = append(cards, found...)
+	}
+
+	sort.Slice(cards, func(i, j int) bool {
+		return cards[i].CardCode < cards[j].CardCode
+	})
+	return copies(cards), nil
+}
+
+// CurrentVersion keeps the current version of each locale, which every
+// search of the current cards needs.
+func (r *Repository) CurrentVersion(ctx context.Context, locale string) (string, error) {
+	if v, ok := r.versions.Get(locale); ok {
+		return v, nil
+	}
+
+	v, err := r.Repository.CurrentVersion(ctx, locale)
+	if err != nil {
+		return "", err
+	}
+	r.versions.Set(locale, v)
+	return v, nil
+}
+
+// SaveBundle saves the bundle and drops the cards kept, as the current
+// version and the cards in it may have changed.
+func (r *Repository) SaveBundle(ctx context.Context, bundle *repository.SetBundle, force bool) error {
+	defer r.Invalidate()
+	return r.Repository.SaveBundle(ctx, bundle, force)
+}
+
+// SaveGlobals saves the globals and drops the current versions kept.
+func (r *Repository) SaveGlobals(ctx context.Context, globals *repository.Globals, force bool) error {
+	defer r.versions.Purge()
+	return r.Repository.SaveGlobals(ctx, globals, force)
+}
+
+// Invalidate drops the cards and versions kept, so they are read again from
+// the wrapped repository.
+func (r *Repository) Invalidate() {
+	r.cards.Purge()
+	r.versions.Purge()
+}
+
+// GuildConfig keeps the settings of each guild.
+func (r *Repository) GuildConfig(ctx context.Context, guild string) (*repository.GuildConfig, error) {
+	if config, ok := r.guilds.Get(guild); ok {
+		config = config.Clone()
+		return &config, nil
+	}
+
+	config, err := r.Repository.GuildConfig(ctx, guild)
+	if err != nil {
+		return nil, err
+	}
+	r.guilds.Set(guild, config.Clone())
+	return config, nil
+}
+
+// UpdateGuildConfig saves the settings of a guild and drops the ones kept.
+func (r *Repository) UpdateGuildConfig(ctx context.Context, guild string, update func(*repository.GuildConfig)) error {
+	defer r.guilds.Remove(guild)
+	return r.Repository.UpdateGuildConfig(ctx, guild, update)
+}
+
+// UserPrefs keeps the preferences of each user.
+func (r *Repository) UserPrefs(ctx context.Context, user string) (*repository.Preferences, error) {
+	if prefs, ok := r.users.Get(user); ok {
+		return &prefs, nil
+	}
+
+	prefs, err := r.Repository.UserPrefs(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	r.users.Set(user, *prefs)
+	return prefs, nil
+}
+
+// UpdateUserPrefs saves the preferences of a user and drops the ones kept.
+func (r *Repository) UpdateUserPrefs(ctx context.Context, user string, update func(*repository.Preferences)) error {
+	defer r.users.Remove(user)
+	return r.Repository.UpdateUserPrefs(ctx, user, update)
+}
+
+// Stats returns the counters of each cache.
+func (r *Repository) Stats() Stats {
+	return Stats{
+		Cards:    r.cards.Counters(),
+		Versions: r.versions.Counters(),
+		Guilds:   r.guilds.Counters(),
+		Users:    r.users.Counters(),
+	}
+}
+
+// copies returns copies of the cards, so callers can change them without
+// changing the cards kept.
+func copies(cards []*repository.Card) []*repository.Card {
+	if cards == nil {
+		return nil
+	}
+	copied := make([]*repository.Card, len(cards))
+	for i, c := range cards {
+		c := *c
+		copied[i] = &c
+	}
+	return copied
+}
@@ -0,0 +1,118 @@
+package cache_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/dneto/sai-scout/internal/cache"
+	"github.com/dneto/sai-scout/internal/repository"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// countingRepository counts the reads that reach the wrapped repository.
+type countingRepository struct {
+	repository.Repository
+	reads int
+}
+
+func (r *countingRepository) FindCards(ctx context.Context, language string, version string, codes ...string) ([]*repository.Card, error) {
+	r.reads++
+	return r.Repository.FindCards(ctx, language, version, codes...)
+}
+
+func (r *countingRepository) GuildConfig(ctx context.Context, guild string) (*repository.GuildConfig, error) {
+	r.reads++
+	return r.Repository.GuildConfig(ctx, guild)
+}
+
+var _ = Describe("Repository", func() {
+	var (
+		ctx   = context.Background()
+		inner *countingRepository
+		repo  *cache.Repository
+	)
+
+	save := func(version string, cost int) {
+		GinkgoHelper()
+		Expect(repo.SaveBundle(ctx, &repository.SetBundle{
+			Set:          "set1",
+			Locale:       "en_us",
+			Version:      version,
+			LastModified: time.Now(),
+			Cards: []*repository.Card{
+				{CardCode: "01DE001", Name: "Vanguard Defender", Cost: cost},
+				{CardCode: "01DE002", Name: "Vanguard Sergeant", Cost: 3},
+			},
+		}, false)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		inner = &countingRepository{Repository: repository.NewMemory()}
+		repo = cache.New(inner, 100, time.Minute)
+		save("4.9.0", 2)
+	})
+
+	It("reads only the cards not kept", func() {
+		_, err := repo.FindCards(ctx, "en_us", "", "01DE001")
+		Expect(err).ToNot(HaveOccurred())
+
+		cards, err := repo.FindCards(ctx, "en_us", "", "01DE002", "01DE001")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cards).To(HaveLen(2))
+		Expect(cards[0].CardCode).To(Equal("01DE001"))
+		Expect(inner.reads).To(Equal(2))
+
+		_, err = repo.FindCards(ctx, "en_us", "", "01DE001", "01DE002")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(inner.reads).To(Equal(2))
+		Expect(repo.Stats().Cards).To(Equal(cache.Counters{Hits: 3, Misses: 2, Size: 2}))
+	})
+
+	It("returns copies of the cards kept", func() {
+		cards, err := repo.FindCards(ctx, "en_us", "", "01DE001")
+		Expect(err).ToNot(HaveOccurred())
+		cards[0].Cost = 10
+
+		cards, err = repo.FindCards(ctx, "en_us", "", "01DE001")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cards[0].Cost).To(Equal(2))
+	})
+
+	It("drops the cards kept when a bundle is saved", func() {
+		_, err := repo.FindCards(ctx, "en_us", "", "01DE001")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(repo.CurrentVersion(ctx, "en_us")).To(Equal("4.9.0"))
+
+		save("4.10.0", 1)
+
+		cards, err := repo.FindCards(ctx, "en_us", "", "01DE001")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cards[0].Cost).To(Equal(1))
+		Expect(repo.CurrentVersion(ctx, "en_us")).To(Equal("4.10.0"))
+	})
+
+	It("keeps guild configurations until they are saved", func() {
+		setLanguage := func(language string) {
+			GinkgoHelper()
+			Expect(repo.UpdateGuildConfig(ctx, "guild", func(c *repository.GuildConfig) {
+				c.Language = language
+			})).To(Succeed())
+		}
+		language := func() string {
+			GinkgoHelper()
+			config, err := repo.GuildConfig(ctx, "guild")
+			Expect(err).ToNot(HaveOccurred())
+			return config.Language
+		}
+
+		setLanguage("pt_br")
+		Expect(language()).To(Equal("pt_br"))
+		Expect(language()).To(Equal("pt_br"))
+		Expect(inner.reads).To(Equal(1))
+
+		setLanguage("es_es")
+		Expect(language()).To(Equal("es_es"))
+		Expect(inner.reads).To(Equal(2))
+	})
+})
@@ -0,0 +1,343 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/dneto/sai-scout/internal/search"
+	"github.com/dneto/sai-scout/pkg/discord"
+	"github.com/dneto/sai-scout/pkg/discord/option"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// maxAliases is the number of aliases a guild can have, so their list
+	// fits in a message.
+	maxAliases = 50
+	// maxAliasLength is the length of the longest alias.
+	maxAliasLength = 32
+	// maxChoices is the number of choices Discord accepts in autocomplete.
+	maxChoices = 25
+)
+
+var aliasPermissions = int64(discordgo.PermissionManageServer)
+
+var aliasCommand = &discordgo.ApplicationCommand{
+	Name:                     "alias",
+	Description:              "Manage the nicknames used for cards in the server",
+	DefaultMemberPermissions: &aliasPermissions,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "add",
+			Description: "Use a nickname for a card, replacing the card it was used for",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "term",
+					Description: "The nickname, like TF or Asol",
+					Type:        discordgo.ApplicationCommandOptionString,
+					MaxLength:   maxAliasLength,
+					Required:    true,
+				},
+				{
+					Name:         "card",
+					Description:  "The card name (autocomplete)",
+					Type:         discordgo.ApplicationCommandOptionString,
+					Required:     true,
+					Autocomplete: true,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "remove",
+			Description: "Stop using a nickname",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:         "term",
+					Description:  "The nickname (autocomplete)",
+					Type:         discordgo.ApplicationCommandOptionString,
+					Required:     true,
+					Autocomplete: true,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "list",
+			Description: "List the nicknames of the server",
+		},
+	},
+}
+
+func Alias(
+	guilds repository.GuildConfigRepository,
+	cards repository.CardRepository,
+	names searchFunc,
+	resolve resolveFunc) *discord.SlashCommand {
+	return discord.NewCommand(aliasCommand, aliasCommandHandler(guilds, cards, names, resolve))
+}
+
+func aliasCommandHandler(
+	guilds repository.GuildConfigRepository,
+	cards repository.CardRepository,
+	names searchFunc,
+	resolve resolveFunc) discord.Handler {
+
+	return func(s discord.Session, i *discordgo.InteractionCreate) error {
+		if i.GuildID == "" {
+			return respondEphemeral(s, i, "Nicknames can only be used in servers")
+		}
+
+		ctx := context.Background()
+		sub := i.ApplicationCommandData().Options[0]
+		language := resolve(ctx, i, repository.Preferences{}).Language
+
+		switch i.Type {
+		case discordgo.InteractionApplicationCommandAutocomplete:
+			return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+				Data: &discordgo.InteractionResponseData{
+					Choices: aliasAutocomplete(ctx, guilds, names, i.GuildID, language, sub),
+				},
+			})
+		case discordgo.InteractionApplicationCommand:
+		default:
+			return nil
+		}
+
+		switch sub.Name {
+		case "add":
+			term := strings.TrimSpace(option.GetOrElse(sub.Options, "term", ""))
+			if term == "" || len([]rune(term)) > maxAliasLength {
+				return respondEphemeral(s, i, fmt.Sprintf("Nicknames must have from 1 to %d characters", maxAliasLength))
+			}
+
+			card, err := findChosenCard(ctx, cards, names, language, option.GetOrElse(sub.Options, "card", ""))
+			if err != nil {
+				log.Error().Err(err).Str("guild", i.GuildID).Msg("failed to find card")
+				return respondEphemeral(s, i, "Failed to find the card")
+			}
+			if card == nil {
+				return respondEphemeral(s, i, "Unknown card, choose one of the suggestions")
+			}
+
+			full := false
+			err = guilds.UpdateGuildConfig(ctx, i.GuildID, func(c *repository.GuildConfig) {
+				c.Aliases, full = addAlias(c.Aliases, repository.Alias{Term: term, CardCode: card.CardCode})
+			})
+			if err != nil {
+				log.Error().Err(err).Str("guild", i.GuildID).Msg("failed to add alias")
+				return respondEphemeral(s, i, "Failed to save the nickname")
+			}
+			if full {
+				return respondEphemeral(s, i, fmt.Sprintf("Servers can have up to %d nicknames, remove some first", maxAliases))
+			}
+			log.Info().Str("guild", i.GuildID).Str("term", term).Str("card", card.CardCode).Msg("added alias")
+			return respondEphemeral(s, i, fmt.Sprintf("Done! `%s` now means %s.", term, card.Name))
+		case "remove":
+			term := option.GetOrElse(sub.Options, "term", "")
+			removed := false
+			err := guilds.UpdateGuildConfig(ctx, i.GuildID, func(c *repository.GuildConfig) {
+				c.Aliases, removed = removeAlias(c.Aliases, term)
+			})
+			if err != nil {
+				log.Error().Err(err).Str("guild", i.GuildID).Msg("failed to remove alias")
+				return respondEphemeral(s, i, "Failed to remove the nickname")
+			}
+			if !removed {
+				return respondEphemeral(s, i, fmt.Sprintf("`%s` is not a nickname", term))
+			}
+			return respondEphemeral(s, i, "Done!")
+		case "list":
+			config, err := guilds.GuildConfig(ctx, i.GuildID)
+			if err != nil {
+				log.Error().Err(err).Str("guild", i.GuildID).Msg("failed to load aliases")
+				return respondEphemeral(s, i, "Failed to load the nicknames")
+			}
+			if len(config.Aliases) == 0 {
+				return respondEphemeral(s, i, "The server has no nicknames, add some with `/alias add`")
+			}
+			named, err := aliasCards(ctx, cards, language, "", config.Aliases)
+			if err != nil {
+				log.Error().Err(err).Str("guild", i.GuildID).Msg("failed to find the cards of aliases")
+			}
+			return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Flags:  discordgo.MessageFlagsEphemeral,
+					Embeds: []*discordgo.MessageEmbed{{Title: "Nicknames", Description: aliasList(config.Aliases, named)}},
+				},
+			})
+		}
+		return nil
+	}
+}
+
+// aliasAutocomplete suggests cards when adding aliases and the aliases of the
+// guild when removing them.
+func aliasAutocomplete(ctx context.Context, guilds repository.GuildConfigRepository, names searchFunc, guild string, language string, sub *discordgo.ApplicationCommandInteractionDataOption) []*discordgo.ApplicationCommandOptionChoice {
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	switch sub.Name {
+	case "add":
+		results, err := names(ctx, language, "", option.GetOrElse(sub.Options, "card", ""))
+		if err != nil {
+			log.Error().Err(err).Msg("failed to search cards")
+		}
+		for _, r := range results {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: choiceName(r), Value: r.Card.CardCode})
+		}
+	case "remove":
+		config, err := guilds.GuildConfig(ctx, guild)
+		if err != nil {
+			log.Error().Err(err).Str("guild", guild).Msg("failed to load aliases")
+			return choices
+		}
+		for _, a := range matchAliases(config.Aliases, option.GetOrElse(sub.Options, "term", "")) {
+			if len(choices) == maxChoices {
+				break
+			}
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: a.Term, Value: a.Term})
+		}
+	}
+	return choices
+}
+
+// findChosenCard returns the card chosen, which is its code when it was
+// suggested or the name typed otherwise. It returns nil if there is no such
+// card.
+func findChosenCard(ctx context.Context, cards repository.CardRepository, names searchFunc, language string, value string) (*repository.Card, error) {
+	found, err := cards.FindCards(ctx, language, "", value)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) > 0 {
+		return found[0], nil
+	}
+
+	results, err := names(ctx, language, "", value)
+	if err != nil || len(results) == 0 {
+		return nil, err
+	}
+	return results[0].Card, nil
+}
+
+// addAlias adds alias, replacing the one of the same term. It returns false
+// if the guild has as many aliases as it can have.
+func addAlias(aliases []repository.Alias, alias repository.Alias) ([]repository.Alias, bool) {
+	for i, a := range aliases {
+		if search.Fold(a.Term) == search.Fold(alias.Term) {
+			aliases[i] = alias
+			return aliases, false
+		}
+	}
+	if len(aliases) >= maxAliases {
+		return aliases, true
+	}
+	return append(aliases, alias), false
+}
+
+// removeAlias removes the alias of a term, returning whether there was one.
+func removeAlias(aliases []repository.Alias, term string) ([]repository.Alias, bool) {
+	for i, a := range aliases {
+		if search.Fold(a.Term) == search.Fold(term) {
+			return append(aliases[:i], aliases[i+1:]...), true
+		}
+	}
+	return aliases, false
+}
+
+// matchAliases returns the aliases whose term starts with the typed name,
+// the one equal to it first and the others by term.
+func matchAliases(aliases []repository.Alias, name string) []repository.Alias {
+	name = search.Fold(strings.TrimSpace(name))
+	var matched []repository.Alias
+	for _, a := range aliases {
+		if strings.HasPrefix(search.Fold(a.Term), name) {
+			matched = append(matched, a)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		ei, ej := search.Fold(matched[i].Term) == name, search.Fold(matched[j].Term) == name
+		if ei != ej {
+			return ei
+		}
+		return search.Fold(matched[i].Term) < search.Fold(matched[j].Term)
+	})
+	return matched
+}
+
+// aliasCards returns the cards of aliases in a language, by card code.
+func aliasCards(ctx context.Context, cards repository.CardRepository, language string, patch string, aliases []repository.Alias) (map[string]*repository.Card, error) {
+	codes := make([]string, len(aliases))
+	for i, a := range aliases {
+		codes[i] = a.CardCode
+	}
+	found, err := cards.FindCards(ctx, language, patch, codes...)
+	byCode := make(map[string]*repository.Card, len(found))
+	for _, c := range found {
+		byCode[c.CardCode] = c
+	}
+	return byCode, err
+}
+
+// aliasChoices suggests the cards of the aliases matching the typed name, with
+// the alias in brackets after the card name.
+func aliasChoices(ctx context.Context, guilds repository.GuildConfigRepository, cards repository.CardRepository, guild string, language string, patch string, name string) []*discordgo.ApplicationCommandOptionChoice {
+	if guilds == nil || guild == "" || strings.TrimSpace(name) == "" {
+		return nil
+	}
+
+	config, err := guilds.GuildConfig(ctx, guild)
+	if err != nil {
+		log.Error().Err(err).Str("guild", guild).Msg("failed to load aliases")
+		return nil
+	}
+	matched := matchAliases(config.Aliases, name)
+	if len(matched) == 0 {
+		return nil
+	}
+
+	named, err := aliasCards(ctx, cards, language, patch, matched)
+	if err != nil {
+		log.Error().Err(err).Str("guild", guild).Msg("failed to find the cards of aliases")
+	}
+
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, a := range matched {
+		if len(choices) == maxChoices {
+			break
+		}
+		c, ok := named[a.CardCode]
+		if !ok {
+			continue
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  choiceName(search.Result{Card: c, Matched: a.Term}),
+			Value: c.CardCode,
+		})
+	}
+	return choices
+}
+
+// aliasList describes the aliases of a guild, by term.
+func aliasList(aliases []repository.Alias, named map[string]*repository.Card) string {
+	sorted := append([]repository.Alias(nil), aliases...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return search.Fold(sorted[i].Term) < search.Fold(sorted[j].Term)
+	})
+
+	lines := make([]string, len(sorted))
+	for i, a := range sorted {
+		name := a.CardCode
+		if c, ok := named[a.CardCode]; ok {
+			name = c.Name
+		}
+		lines[i] = fmt.Sprintf("`%s` → %s", a.Term, name)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/dneto/sai-scout/internal/search"
+	"github.com/dneto/sai-scout/pkg/discord"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AliasCommand", func() {
+	var (
+		ctx      = context.Background()
+		repo     *repository.Memory
+		names    *search.Cards
+		handler  discord.Handler
+		response *discordgo.InteractionResponse
+		session  discord.Session = fakeSession{
+			interactionRespond: func(i *discordgo.Interaction, ir *discordgo.InteractionResponse, opts ...discordgo.RequestOption) error {
+				response = ir
+				return nil
+			},
+		}
+	)
+
+	run := func(typ discordgo.InteractionType, sub string, options ...*discordgo.ApplicationCommandInteractionDataOption) {
+		GinkgoHelper()
+		Expect(handler(session, subCommand(typ, "alias", sub, options...))).To(Succeed())
+	}
+
+	aliases := func() []repository.Alias {
+		GinkgoHelper()
+		config, err := repo.GuildConfig(ctx, "guild")
+		Expect(err).ToNot(HaveOccurred())
+		return config.Aliases
+	}
+
+	BeforeEach(func() {
+		repo = repository.NewMemory()
+		Expect(repo.SaveBundle(ctx, &repository.SetBundle{
+			Set: "set1", Locale: "en_us", Version: "4.9.0", LastModified: time.Now(),
+			Cards: []*repository.Card{
+				{CardCode: "01PZ040", Name: "Twisted Fate", Collectible: true},
+				{CardCode: "01IO001", Name: "Jhin", Collectible: true},
+			},
+		}, false)).To(Succeed())
+		names = search.NewCards(repo)
+		handler = aliasCommandHandler(repo, names, names.SearchAllLocales, fakeResolve)
+	})
+
+	It("adds aliases by card code", func() {
+		run(discordgo.InteractionApplicationCommand, "add", stringOption("term", "TF"), stringOption("card", "01PZ040"))
+		Expect(response.Data.Content).To(Equal("Done! `TF` now means Twisted Fate."))
+		Expect(aliases()).To(Equal([]repository.Alias{{Term: "TF", CardCode: "01PZ040"}}))
+	})
+
+	It("adds aliases by card name", func() {
+		run(discordgo.InteractionApplicationCommand, "add", stringOption("term", "TF"), stringOption("card", "twisted"))
+		Expect(aliases()).To(Equal([]repository.Alias{{Term: "TF", CardCode: "01PZ040"}}))
+	})
+
+	It("replaces the alias of the same term", func() {
+		run(discordgo.InteractionApplicationCommand, "add", stringOption("term", "TF"), stringOption("card", "01PZ040"))
+		run(discordgo.InteractionApplicationCommand, "add", stringOption("term", "tf"), stringOption("card", "01IO001"))
+		Expect(aliases()).To(Equal([]repository.Alias{{Term: "tf", CardCode: "01IO001"}}))
+	})
+
+	It("rejects unknown cards", func() {
+		run(discordgo.InteractionApplicationCommand, "add", stringOption("term", "TF"), stringOption("card", "nothing like it"))
+		Expect(response.Data.Content).To(Equal("Unknown card, choose one of the suggestions"))
+		Expect(aliases()).To(BeEmpty())
+	})
+
+	It("limits the aliases of a guild", func() {
+		Expect(repo.UpdateGuildConfig(ctx, "guild", func(c *repository.GuildConfig) {
+			for i := 0; i < maxAliases; i++ {
+				c.Aliases = append(c.Aliases, repository.Alias{Term: fmt.Sprint(i), CardCode: "01IO001"})
+			}
+		})).To(Succeed())
+
+		run(discordgo.InteractionApplicationCommand, "add", stringOption("term", "TF"), stringOption("card", "01PZ040"))
+		Expect(response.Data.Content).To(ContainSubstring("up to"))
+		Expect(aliases()).To(HaveLen(maxAliases))
+	})
+
+	It("removes and lists aliases", func() {
+		run(discordgo.InteractionApplicationCommand, "add", stringOption("term", "TF"), stringOption("card", "01PZ040"))
+		run(discordgo.InteractionApplicationCommand, "add", stringOption("term", "Jh"), stringOption("card", "01IO001"))
+
+		run(discordgo.InteractionApplicationCommand, "list")
+		Expect(response.Data.Embeds[0].Description).To(Equal("`Jh` → Jhin\n`TF` → Twisted Fate"))
+
+		run(discordgo.InteractionApplicationCommand, "remove", stringOption("term", "tf"))
+		Expect(response.Data.Content).To(Equal("Done!"))
+		Expect(aliases()).To(Equal([]repository.Alias{{Term: "Jh", CardCode: "01IO001"}}))
+
+		run(discordgo.InteractionApplicationCommand, "remove", stringOption("term", "tf"))
+		Expect(response.Data.Content).To(Equal("`tf` is not a nickname"))
+	})
+
+	It("suggests the aliases to remove", func() {
+		run(discordgo.InteractionApplicationCommand, "add", stringOption("term", "TF"), stringOption("card", "01PZ040"))
+		run(discordgo.InteractionApplicationCommandAutocomplete, "remove", stringOption("term", "t"))
+		Expect(response.Data.Choices).To(Equal([]*discordgo.ApplicationCommandOptionChoice{{Name: "TF", Value: "TF"}}))
+	})
+
+	It("suggests the aliases first in /info", func() {
+		run(discordgo.InteractionApplicationCommand, "add", stringOption("term", "Jh"), stringOption("card", "01PZ040"))
+
+		in := &discordgo.InteractionCreate{
+			Interaction: &discordgo.Interaction{
+				Type:    discordgo.InteractionApplicationCommandAutocomplete,
+				GuildID: "guild",
+				Data: discordgo.ApplicationCommandInteractionData{
+					Name:    "info",
+					Options: []*discordgo.ApplicationCommandInteractionDataOption{stringOption("name", "jh")},
+				},
+			},
+		}
+		resp := infoAutocompleteHandler(names, names.SearchAllLocales, repo, fakeResolve, in)
+		Expect(resp.Data.Choices).To(Equal([]*discordgo.ApplicationCommandOptionChoice{
+			{Name: "Twisted Fate [Jh]", Value: "01PZ040"},
+			{Name: "Jhin", Value: "01IO001"},
+		}))
+	})
+})
@@ -7,14 +7,13 @@ import (
 	"strings"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/dneto/sai-scout/internal/repository"
 	"github.com/dneto/sai-scout/pkg/discord"
+	"github.com/dneto/sai-scout/pkg/discord/option"
 	"github.com/rs/zerolog/log"
 )
 
-var Config = func(
-	saveLang func(context.Context, string, string) error,
-	saveTemplate func(context.Context, string, string, string) error,
-) *discord.SlashCommand {
+var Config = func(guilds repository.GuildConfigRepository) *discord.SlashCommand {
 	permissions := int64(discordgo.PermissionManageServer)
 	return discord.NewCommand(&discordgo.ApplicationCommand{
 		Name:                     "config",
@@ -25,32 +24,53 @@ var Config = func(
 				Type:        discordgo.ApplicationCommandOptionSubCommand,
 				Name:        "language",
 				Description: "Set the default language output",
+				Options:     []*discordgo.ApplicationCommandOption{languageValueOption},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "website",
+				Description: "Set the website to view decks",
+				Options:     websiteOptions,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "locale",
+				Description: "Choose whose Discord language is used when no language is set",
 				Options: []*discordgo.ApplicationCommandOption{
 					{
 						Name:        "value",
-						Description: "Language",
+						Description: "Whose Discord language wins",
 						Type:        discordgo.ApplicationCommandOptionString,
-						Choices:     i18nToOptions(),
-						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Each user's", Value: localeUser},
+							{Name: "The server's", Value: localeServer},
+						},
+						Required: true,
 					},
 				},
 			},
 			{
-				Type:        discordgo.ApplicationCommandOptionSubCommand,
-				Name:        "website",
-				Description: "Set the website to view decks",
+				Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+				Name:        "channel",
+				Description: "Manage the configuration of a channel, which overrides the server one",
 				Options: []*discordgo.ApplicationCommandOption{
 					{
-						Name:        "template",
-						Description: "Example: https://runeterra.ar/decks/code/{{code}}",
-						Type:        discordgo.ApplicationCommandOptionString,
-						Required:    true,
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "language",
+						Description: "Set the language output in a channel",
+						Options:     []*discordgo.ApplicationCommandOption{languageValueOption, channelOption},
 					},
 					{
-						Name:        "name",
-						Description: "Website's name to be shown in \"View on\" button",
-						Type:        discordgo.ApplicationCommandOptionString,
-						Required:    true,
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "website",
+						Description: "Set the website to view decks in a channel",
+						Options:     append(websiteOptions[:len(websiteOptions):len(websiteOptions)], channelOption),
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "clear",
+						Description: "Use the server configuration in a channel again",
+						Options:     []*discordgo.ApplicationCommandOption{channelOption},
 					},
 				},
 			},
@@ -64,7 +84,10 @@ var Config = func(
 			switch split[1] {
 			case "OK":
 				label, template := split[2], split[3]
-				if err := saveTemplate(context.Background(), i.GuildID, template, label); err != nil {
+				err := guilds.UpdateGuildConfig(context.Background(), i.GuildID, func(c *repository.GuildConfig) {
+					c.URLTemplate, c.URLLabel = template, label
+				})
+				if err != nil {
 					log.Error().Err(err)
 					return discord.ErrorResponse(s, i, err)
 				}
@@ -88,10 +111,25 @@ var Config = func(
 			data := i.ApplicationCommandData()
 			options := data.Options
 			switch options[0].Name {
+			case "channel":
+				return configChannelHandler(s, i, guilds, options[0].Options[0])
+			case "locale":
+				guildFirst := options[0].Options[0].StringValue() == localeServer
+				err := guilds.UpdateGuildConfig(context.Background(), i.GuildID, func(c *repository.GuildConfig) {
+					c.GuildLocaleFirst = guildFirst
+				})
+				if err != nil {
+					log.Error().Err(err).Str("guild", i.GuildID).Msg("failed to update locale config")
+					return respondEphemeral(s, i, "Failed to save the configuration")
+				}
+				return respondEphemeral(s, i, "Done!")
 			case "language":
 				language := options[0].Options[0].StringValue()
 				log.Info().Str("guild", i.GuildID).Str("language", language).Msg("updating lang")
-				if err := saveLang(context.Background(), i.GuildID, language); err != nil {
+				err := guilds.UpdateGuildConfig(context.Background(), i.GuildID, func(c *repository.GuildConfig) {
+					c.Language = language
+				})
+				if err != nil {
 					log.Error().Err(err)
 					return discord.ErrorResponse(s, i, err)
 				}
@@ -121,9 +159,7 @@ var Config = func(
 					log.Error().Err(err)
 				}
 
-				uaaa, err := url.Parse(template)
-				scheme := uaaa.Scheme
-				if err != nil || (scheme != "http" && scheme != "https") {
+				if !validURLTemplate(template) {
 					return discord.ErrorResponse(s, i, fmt.Errorf("Malformed URL"))
 				}
 
@@ -169,3 +205,104 @@ var Config = func(
 		return nil
 	})
 }
+
+// Choices of /config locale.
+const (
+	localeUser   = "user"
+	localeServer = "server"
+)
+
+var languageValueOption = &discordgo.ApplicationCommandOption{
+	Name:        "value",
+	Description: "Language",
+	Type:        discordgo.ApplicationCommandOptionString,
+	Choices:     i18nToOptions(),
+	Required:    true,
+}
+
+var websiteOptions = []*discordgo.ApplicationCommandOption{
+	{
+		Name:        "template",
+		Description: "Example: https://runeterra.ar/decks/code/{{code}}",
+		Type:        discordgo.ApplicationCommandOptionString,
+		Required:    true,
+	},
+	{
+		Name:        "name",
+		Description: "Website's name to be shown in \"View on\" button",
+		Type:        discordgo.ApplicationCommandOptionString,
+		Required:    true,
+	},
+}
+
+var channelOption = &discordgo.ApplicationCommandOption{
+	Name:         "channel",
+	Description:  "Channel to configure (default: this channel)",
+	Type:         discordgo.ApplicationCommandOptionChannel,
+	ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+	Required:     false,
+}
+
+// configChannelHandler saves the preferences of a channel, which win over the
+// ones of the guild.
+func configChannelHandler(s discord.Session, i *discordgo.InteractionCreate, guilds repository.GuildConfigRepository, sub *discordgo.ApplicationCommandInteractionDataOption) error {
+	channel := option.GetOrElse(sub.Options, "channel", i.ChannelID)
+
+	var update func(*repository.Preferences)
+	switch sub.Name {
+	case "language":
+		language := option.GetOrElse(sub.Options, "value", "")
+		update = func(p *repository.Preferences) {
+			p.Language = language
+		}
+	case "website":
+		template, label := option.GetOrElse(sub.Options, "template", ""), option.GetOrElse(sub.Options, "name", "")
+		if !validURLTemplate(template) {
+			return respondEphemeral(s, i, "Malformed URL")
+		}
+		update = func(p *repository.Preferences) {
+			p.URLTemplate, p.URLLabel = template, label
+		}
+	case "clear":
+		update = func(p *repository.Preferences) {
+			*p = repository.Preferences{}
+		}
+	default:
+		return nil
+	}
+
+	log.Info().Str("guild", i.GuildID).Str("channel", channel).Str("setting", sub.Name).Msg("updating channel config")
+	err := guilds.UpdateGuildConfig(context.Background(), i.GuildID, func(c *repository.GuildConfig) {
+		prefs := c.Channels[channel]
+		update(&prefs)
+		if c.Channels == nil {
+			c.Channels = make(map[string]repository.Preferences)
+		}
+		if prefs == (repository.Preferences{}) {
+			delete(c.Channels, channel)
+		} else {
+			c.Channels[channel] = prefs
+		}
+	})
+	if err != nil {
+		log.Error().Err(err).Str("guild", i.GuildID).Msg("failed to update channel config")
+		return respondEphemeral(s, i, "Failed to save the configuration")
+	}
+	return respondEphemeral(s, i, "Done!")
+}
+
+// validURLTemplate reports whether a website template is a http or https URL.
+func validURLTemplate(template string) bool {
+	u, err := url.Parse(template)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+}
+
+func respondEphemeral(s discord.Session, i *discordgo.InteractionCreate, content string) error {
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Content: content,
+		},
+	})
+}
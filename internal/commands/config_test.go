@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/dneto/sai-scout/pkg/discord"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConfigCommand", func() {
+	Context("channel", func() {
+		var (
+			repo    *repository.Memory
+			session discord.Session = fakeSession{
+				interactionRespond: func(*discordgo.Interaction, *discordgo.InteractionResponse, ...discordgo.RequestOption) error {
+					return nil
+				},
+			}
+		)
+
+		run := func(sub string, options ...*discordgo.ApplicationCommandInteractionDataOption) {
+			GinkgoHelper()
+			Expect(configChannelHandler(session, &discordgo.InteractionCreate{
+				Interaction: &discordgo.Interaction{GuildID: "guild", ChannelID: "here"},
+			}, repo, &discordgo.ApplicationCommandInteractionDataOption{Name: sub, Options: options})).To(Succeed())
+		}
+
+		option := func(name string, t discordgo.ApplicationCommandOptionType, value string) *discordgo.ApplicationCommandInteractionDataOption {
+			return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: t, Value: value}
+		}
+
+		channels := func() map[string]repository.Preferences {
+			GinkgoHelper()
+			config, err := repo.GuildConfig(context.Background(), "guild")
+			Expect(err).ToNot(HaveOccurred())
+			return config.Channels
+		}
+
+		BeforeEach(func() {
+			repo = repository.NewMemory()
+		})
+
+		It("configures the current channel", func() {
+			run("language", option("value", discordgo.ApplicationCommandOptionString, "pt_br"))
+			Expect(channels()).To(Equal(map[string]repository.Preferences{"here": {Language: "pt_br"}}))
+		})
+
+		It("configures the given channel", func() {
+			run("website",
+				option("template", discordgo.ApplicationCommandOptionString, "https://example.com/{{code}}"),
+				option("name", discordgo.ApplicationCommandOptionString, "Example"),
+				option("channel", discordgo.ApplicationCommandOptionChannel, "there"),
+			)
+			Expect(channels()).To(Equal(map[string]repository.Preferences{
+				"there": {URLTemplate: "https://example.com/{{code}}", URLLabel: "Example"},
+			}))
+		})
+
+		It("reads the options by name", func() {
+			run("language",
+				option("channel", discordgo.ApplicationCommandOptionChannel, "there"),
+				option("value", discordgo.ApplicationCommandOptionString, "pt_br"),
+			)
+			run("website",
+				option("channel", discordgo.ApplicationCommandOptionChannel, "there"),
+				option("name", discordgo.ApplicationCommandOptionString, "Example"),
+				option("template", discordgo.ApplicationCommandOptionString, "https://example.com/{{code}}"),
+			)
+			Expect(channels()).To(Equal(map[string]repository.Preferences{
+				"there": {Language: "pt_br", URLTemplate: "https://example.com/{{code}}", URLLabel: "Example"},
+			}))
+		})
+
+		It("clears the channel configuration", func() {
+			run("language", option("value", discordgo.ApplicationCommandOptionString, "pt_br"))
+			run("clear")
+			Expect(channels()).To(BeEmpty())
+		})
+	})
+})
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/dneto/sai-scout/internal/regions"
 	"github.com/dneto/sai-scout/internal/repository"
 	"github.com/dneto/sai-scout/pkg/discord"
+	"github.com/dneto/sai-scout/pkg/discord/embed"
 	"github.com/dneto/sai-scout/pkg/discord/option"
 	"github.com/rs/zerolog/log"
 	"github.com/samber/lo"
@@ -19,11 +21,14 @@ import (
 )
 
 func Deck(
-	decoder decodeFunc,
+	cards repository.CardRepository,
+	resolve resolveFunc,
 	localize localizeFunc,
-	findLang getLangFunc,
-	getTemplate getTemplateFunc,
 ) *discord.SlashCommand {
+	var decode decodeFunc
+	if cards != nil {
+		decode = deck.BuildLoadDeckInfo(cards.FindCards)
+	}
 	return discord.NewCommand(
 		&discordgo.ApplicationCommand{
 			Name:        "deck",
@@ -42,12 +47,21 @@ func Deck(
 					Choices:     i18nToOptions(),
 					Required:    false,
 				},
+				patchOption,
 			},
 		},
-		deckCommandHandler(decoder, localize, findLang, getTemplate),
+		deckCommandHandler(decode, localize, resolve),
 	)
 }
 
+// patchOption lets users see cards as they were in a previous patch.
+var patchOption = &discordgo.ApplicationCommandOption{
+	Name:        "patch",
+	Description: "Show cards as of a game patch, e.g. 4.9.0 (default: current)",
+	Type:        discordgo.ApplicationCommandOptionString,
+	Required:    false,
+}
+
 func i18nToOptions() []*discordgo.ApplicationCommandOptionChoice {
 	opts := make([]*discordgo.ApplicationCommandOptionChoice, len(i18n.Locales))
 	for i, locale := range i18n.Locales {
@@ -62,8 +76,7 @@ func i18nToOptions() []*discordgo.ApplicationCommandOptionChoice {
 func deckCommandHandler(
 	decode decodeFunc,
 	localize localizeFunc,
-	findLang getLangFunc,
-	getTemplate getTemplateFunc,
+	resolve resolveFunc,
 ) func(s discord.Session, i *discordgo.InteractionCreate) error {
 	return func(s discord.Session, i *discordgo.InteractionCreate) error {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -80,110 +93,166 @@ func deckCommandHandler(
 		i.ApplicationCommandData()
 		options := i.ApplicationCommandData().Options
 		deckCode := options[0].Value.(string)
-		defaultLang, _ := findLang(context.Background(), i.GuildID)
-		if defaultLang == "" {
-			defaultLang = string(i18n.Default)
-		}
-		language := option.GetOrElse(options, "language", defaultLang)
+		prefs := resolve(context.Background(), i, repository.Preferences{
+			Language: option.GetOrElse(options, "language", ""),
+		})
+		language := prefs.Language
+		patch := option.GetOrElse(options, "patch", "")
 
-		decodedDeck, err := decode(context.Background(), language, deckCode)
+		decodedDeck, err := decode(context.Background(), language, patch, deckCode)
 
+		if errors.Is(err, repository.ErrInvalidVersion) {
+			return discord.ErrorResponse(s, i, fmt.Errorf("**%s** is not a valid patch", patch))
+		}
 		if err != nil {
 			log.Err(err).Str("code", deckCode).Str("language", language).Msg("failed to decode deck")
 			return discord.ErrorResponse(s, i, fmt.Errorf("**%s** is a invalid code", deckCode))
 		}
 
-		filter := func(d deck.Deck, predicate func(c *repository.Card) bool) []deck.DeckEntry {
-			return lo.Filter(d, func(den deck.DeckEntry, _ int) bool {
-				return predicate(den.Card)
-			})
+		embeds := []*discordgo.MessageEmbed{
+			{
+				Title:       deckCode,
+				Description: legalityDescription(deck.Validate(decodedDeck), language, localize),
+				Fields:      deckFields(decodedDeck, language, localize),
+			},
 		}
 
-		cardsByType := map[string][]deck.DeckEntry{
-			"Champions":  filter(decodedDeck, card.IsChampion),
-			"Followers":  filter(decodedDeck, card.IsFollower),
-			"Spells":     filter(decodedDeck, card.IsSpell),
-			"Landmarks":  filter(decodedDeck, card.IsLandmark),
-			"Equipments": filter(decodedDeck, card.IsEquipment),
-		}
+		embeds[0].Footer = memberFooter(i)
+		_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Embeds:     embeds,
+			Components: deckLink(prefs, deckCode),
+		})
 
-		typesShowOrder := []string{"Champions", "Followers", "Spells", "Landmarks", "Equipments"}
-		fields := []*discordgo.MessageEmbedField{}
-		for _, t := range typesShowOrder {
-			if len(cardsByType[t]) == 0 {
-				continue
-			}
+		if err != nil {
+			log.Error().Err(err).Msg("failed to send deck followup message")
+		}
 
-			cards := lo.Map(cardsByType[t], func(de deck.DeckEntry, _ int) string {
-				return cardToStr(de)
-			})
+		return err
+	}
+}
 
-			cs := lo.Chunk(cards, 10)
+// deckFields lists the cards of a deck by type, with up to 10 cards per
+// field.
+func deckFields(d deck.Deck, language string, localize localizeFunc) []*discordgo.MessageEmbedField {
+	filter := func(d deck.Deck, predicate func(c *repository.Card) bool) []deck.DeckEntry {
+		return lo.Filter(d, func(den deck.DeckEntry, _ int) bool {
+			return predicate(den.Card)
+		})
+	}
 
-			fields = append(fields, &discordgo.MessageEmbedField{
-				Name:   localize(language, t),
-				Value:  strings.Join(cs[0], "\n"),
-				Inline: true,
-			})
+	cardsByType := map[string][]deck.DeckEntry{
+		"Champions":  filter(d, card.IsChampion),
+		"Followers":  filter(d, card.IsFollower),
+		"Spells":     filter(d, card.IsSpell),
+		"Landmarks":  filter(d, card.IsLandmark),
+		"Equipments": filter(d, card.IsEquipment),
+	}
 
-			for _, c := range cs[1:] {
-				title := "ㅤ"
-				inline := true
-				fields = append(fields, &discordgo.MessageEmbedField{
-					Name:   title,
-					Value:  strings.Join(c, "\n"),
-					Inline: inline,
-				})
-			}
+	typesShowOrder := []string{"Champions", "Followers", "Spells", "Landmarks", "Equipments"}
+	fields := []*discordgo.MessageEmbedField{}
+	for _, t := range typesShowOrder {
+		if len(cardsByType[t]) == 0 {
+			continue
 		}
 
-		embeds := []*discordgo.MessageEmbed{
-			{
-				Title:  deckCode,
-				Fields: fields,
-			},
-		}
+		cards := lo.Map(cardsByType[t], func(de deck.DeckEntry, _ int) string {
+			return cardToStr(de)
+		})
 
-		if i.Interaction.Member != nil {
-			name := i.Interaction.Member.Nick
-			if name == "" {
-				name = i.Interaction.Member.User.GlobalName
-			}
-
-			icon := avatarURL(i)
-			embeds[0].Footer = &discordgo.MessageEmbedFooter{
-				Text:    name,
-				IconURL: icon,
-			}
+		cs := lo.Chunk(cards, 10)
+
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   localize(language, t),
+			Value:  strings.Join(cs[0], "\n"),
+			Inline: true,
+		})
+
+		for _, c := range cs[1:] {
+			title := "ㅤ"
+			inline := true
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name:   title,
+				Value:  strings.Join(c, "\n"),
+				Inline: inline,
+			})
 		}
-		template, label, _ := getTemplate(context.Background(), i.GuildID)
-		if template == "" {
-			template = "https://runeterra.ar/lor/decks/code/{{code}}"
+	}
+	return fields
+}
+
+// legalityDescription is a badge telling whether a deck can be played in each
+// official format, followed by the rules it breaks, in a language.
+func legalityDescription(l deck.Legality, language string, localize localizeFunc) string {
+	badges := make([]string, len(deck.Formats))
+	var violations []string
+	for i, f := range deck.Formats {
+		mark := "✅"
+		if !l.Legal(f) {
+			mark = "❌"
 		}
-		if label == "" {
-			label = "Runeterra AR"
+		badges[i] = fmt.Sprintf("%s %s", mark, localize(language, string(f)))
+	}
+	for _, v := range l.Violations {
+		violations = append(violations, violationText(v, language, localize))
+	}
+	for _, f := range deck.Formats {
+		if len(l.Illegal[f]) > 0 {
+			violations = append(violations, fmt.Sprintf(localize(language, "Not In Format"),
+				localize(language, string(f)), strings.Join(l.Illegal[f], ", ")))
 		}
-		url := strings.Replace(template, "{{code}}", deckCode, 1)
-		_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-			Embeds: embeds,
+	}
+
+	lines := []string{strings.Join(badges, " · ")}
+	for _, v := range violations {
+		lines = append(lines, "• "+v)
+	}
+	return embed.Truncate(strings.Join(lines, "\n"), 4096)
+}
+
+// violationText describes a rule a deck breaks in a language.
+func violationText(v deck.Violation, language string, localize localizeFunc) string {
+	switch v.Rule {
+	case deck.SizeRule:
+		return fmt.Sprintf(localize(language, "Deck Size"), v.Count, v.Limit)
+	case deck.CopiesRule:
+		return fmt.Sprintf(localize(language, "Card Copies"), v.Card, v.Count, v.Limit)
+	case deck.ChampionsRule:
+		return fmt.Sprintf(localize(language, "Champion Count"), v.Count, v.Limit)
+	default:
+		return fmt.Sprintf(localize(language, "Region Count"), v.Count, strings.Join(v.Regions, ", "), v.Limit)
+	}
+}
+
+// deckLink is a button linking a deck code to the website chosen in prefs.
+func deckLink(prefs repository.Preferences, code string) []discordgo.MessageComponent {
+	url := strings.Replace(prefs.URLTemplate, "{{code}}", code, 1)
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
 			Components: []discordgo.MessageComponent{
-				discordgo.ActionsRow{
-					Components: []discordgo.MessageComponent{
-						discordgo.Button{
-							Style: discordgo.LinkButton,
-							Label: fmt.Sprintf("View on %s", label),
-							URL:   url,
-						},
-					},
+				discordgo.Button{
+					Style: discordgo.LinkButton,
+					Label: fmt.Sprintf("View on %s", prefs.URLLabel),
+					URL:   url,
 				},
 			},
-		})
+		},
+	}
+}
 
-		if err != nil {
-			log.Error().Err(err).Msg("failed to send deck followup message")
-		}
+// memberFooter shows the guild member who ran a command, or nothing outside
+// guilds.
+func memberFooter(i *discordgo.InteractionCreate) *discordgo.MessageEmbedFooter {
+	if i.Interaction.Member == nil {
+		return nil
+	}
 
-		return err
+	name := i.Interaction.Member.Nick
+	if name == "" {
+		name = i.Interaction.Member.User.GlobalName
+	}
+	return &discordgo.MessageEmbedFooter{
+		Text:    name,
+		IconURL: avatarURL(i),
 	}
 }
 
@@ -6,6 +6,7 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/dneto/sai-scout/internal/deck"
+	"github.com/dneto/sai-scout/internal/deck/decktest"
 	"github.com/dneto/sai-scout/internal/i18n"
 	"github.com/dneto/sai-scout/pkg/discord"
 	. "github.com/onsi/ginkgo/v2"
@@ -20,7 +21,7 @@ var _ = Describe("DeckCommand", func() {
 		)
 
 		BeforeEach(func() {
-			command = Deck(nil, nil)
+			command = Deck(nil, nil, localize)
 		})
 
 		It("should have name 'deck'", func() {
@@ -53,7 +54,7 @@ var _ = Describe("DeckCommand", func() {
 		Context("sucess", func() {
 			BeforeEach(func() {
 
-				decodeFunc := decodeFunc(func(ctx context.Context, language, code string) (deck.Deck, error) {
+				decodeFunc := decodeFunc(func(ctx context.Context, language, version, code string) (deck.Deck, error) {
 					return deck.Deck{
 						deck.DeckEntry{Card: annie, Count: 1},
 						deck.DeckEntry{Card: ravenbloomConservatory, Count: 1},
@@ -63,7 +64,7 @@ var _ = Describe("DeckCommand", func() {
 					}, nil
 				})
 
-				h := deckCommandHandler(decodeFunc, localize)
+				h := deckCommandHandler(decodeFunc, localize, fakeResolve)
 				interaction := &discordgo.InteractionCreate{
 					Interaction: &discordgo.Interaction{
 						Type: discordgo.InteractionApplicationCommand,
@@ -140,7 +141,7 @@ var _ = Describe("DeckCommand", func() {
 							discordgo.Button{
 								Style: discordgo.LinkButton,
 								Label: "View on Runeterra AR",
-								URL:   "https://runeterra.ar/decks/code/" + "DECKCODE",
+								URL:   "https://runeterra.ar/lor/decks/code/" + "DECKCODE",
 							},
 						},
 					},
@@ -150,7 +151,7 @@ var _ = Describe("DeckCommand", func() {
 
 		Context("contains more than 10 cards of a type", func() {
 			BeforeEach(func() {
-				decodeFunc := decodeFunc(func(_ context.Context, language, code string) (deck.Deck, error) {
+				decodeFunc := decodeFunc(func(_ context.Context, language, version, code string) (deck.Deck, error) {
 					return deck.Deck{
 						deck.DeckEntry{Card: annie, Count: 1},
 						deck.DeckEntry{Card: annie, Count: 1},
@@ -166,7 +167,7 @@ var _ = Describe("DeckCommand", func() {
 					}, nil
 				})
 
-				h := deckCommandHandler(decodeFunc, localize)
+				h := deckCommandHandler(decodeFunc, localize, fakeResolve)
 				interaction := &discordgo.InteractionCreate{
 					Interaction: &discordgo.Interaction{
 						Type: discordgo.InteractionApplicationCommand,
@@ -200,11 +201,11 @@ var _ = Describe("DeckCommand", func() {
 
 		Context("fields without cards are not showed", func() {
 			BeforeEach(func() {
-				decodeFunc := decodeFunc(func(_ context.Context, language, code string) (deck.Deck, error) {
+				decodeFunc := decodeFunc(func(_ context.Context, language, version, code string) (deck.Deck, error) {
 					return deck.Deck{deck.DeckEntry{Card: annie, Count: 1}}, nil
 				})
 
-				h := deckCommandHandler(decodeFunc, localize)
+				h := deckCommandHandler(decodeFunc, localize, fakeResolve)
 				interaction := &discordgo.InteractionCreate{
 					Interaction: &discordgo.Interaction{
 						Type: discordgo.InteractionApplicationCommand,
@@ -227,13 +228,59 @@ var _ = Describe("DeckCommand", func() {
 			})
 		})
 
+		Context("legality", func() {
+			run := func(d deck.Deck, options ...*discordgo.ApplicationCommandInteractionDataOption) {
+				GinkgoHelper()
+				h := deckCommandHandler(func(_ context.Context, language, version, code string) (deck.Deck, error) {
+					return d, nil
+				}, localize, fakeResolve)
+				Expect(h(session, &discordgo.InteractionCreate{
+					Interaction: &discordgo.Interaction{
+						Type: discordgo.InteractionApplicationCommand,
+						Data: discordgo.ApplicationCommandInteractionData{
+							Options: append([]*discordgo.ApplicationCommandInteractionDataOption{
+								{Value: "DECKCODE"},
+							}, options...),
+						},
+					},
+				})).To(Succeed())
+			}
+
+			It("shows a badge for legal decks", func() {
+				run(decktest.Legal())
+				Expect(followUpResp.Embeds[0].Description).To(Equal("✅ Standard · ✅ Eternal"))
+			})
+
+			It("lists the rules broken", func() {
+				d := decktest.Legal()
+				d[2].Count = 4
+				d[3].Card.FormatRefs = []string{deck.Eternal.Ref()}
+				run(d)
+				Expect(followUpResp.Embeds[0].Description).To(Equal("❌ Standard · ❌ Eternal\n" +
+					"• The deck has 42 cards instead of 40\n" +
+					"• 01XX000 has 4 copies, decks can have up to 3\n" +
+					"• Not in Standard: 01XX001"))
+			})
+
+			It("lists the rules broken in the language of the command", func() {
+				d := decktest.Legal()
+				d[2].Card.RegionRefs = []string{"Ionia"}
+				d[3].Card.FormatRefs = nil
+				run(d, stringOption("language", "pt_br"))
+				Expect(followUpResp.Embeds[0].Description).To(Equal("❌ Padrão · ❌ Eterno\n" +
+					"• O deck tem cartas de 3 regiões (Demacia, Ionia, Noxus), decks podem ter até 2\n" +
+					"• Fora do formato Padrão: 01XX001\n" +
+					"• Fora do formato Eterno: 01XX001"))
+			})
+		})
+
 		Context("invalid deck code", func() {
 			BeforeEach(func() {
-				decodeFunc := decodeFunc(func(_ context.Context, language, code string) (deck.Deck, error) {
+				decodeFunc := decodeFunc(func(_ context.Context, language, version, code string) (deck.Deck, error) {
 					return nil, errors.New("error while decoding")
 				})
 
-				h := deckCommandHandler(decodeFunc, localize)
+				h := deckCommandHandler(decodeFunc, localize, fakeResolve)
 				interaction := &discordgo.InteractionCreate{
 					Interaction: &discordgo.Interaction{
 						Type: discordgo.InteractionApplicationCommand,
@@ -249,9 +296,9 @@ var _ = Describe("DeckCommand", func() {
 
 			It("returns invalid deck code error", func() {
 				Expect(followUpResp).To(Equal(&discordgo.WebhookParams{
-					Flags: discordgo.MessageFlagsEphemeral,
+					Content: "### <:poroshock:1151475832494247947> **Error**",
 					Embeds: []*discordgo.MessageEmbed{
-						{Description: ":x: **invalid code**"},
+						{Description: "**DECKCODE** is a invalid code"},
 					},
 				}))
 			})
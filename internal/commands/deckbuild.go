@@ -0,0 +1,394 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dneto/sai-scout/internal/card"
+	"github.com/dneto/sai-scout/internal/deck"
+	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/dneto/sai-scout/internal/search"
+	"github.com/dneto/sai-scout/pkg/discord"
+	"github.com/dneto/sai-scout/pkg/discord/option"
+	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
+)
+
+var (
+	minCopiesOption = 1.0
+	maxCopiesOption = float64(deck.MaxCopies)
+)
+
+var deckBuildCommand = &discordgo.ApplicationCommand{
+	Name:        "deckbuild",
+	Description: "Build a deck card by card and get its code",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "start",
+			Description: "Start a new deck, dropping the one being built",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "code",
+					Description: "A deck code to start from",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Required:    false,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "add",
+			Description: "Add copies of a card to the deck",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:         "card",
+					Description:  "The card name (autocomplete)",
+					Type:         discordgo.ApplicationCommandOptionString,
+					Required:     true,
+					Autocomplete: true,
+				},
+				{
+					Name:        "count",
+					Description: "Number of copies (default: 1)",
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					MinValue:    &minCopiesOption,
+					MaxValue:    maxCopiesOption,
+					Required:    false,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "remove",
+			Description: "Remove copies of a card from the deck",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:         "card",
+					Description:  "The card name (autocomplete)",
+					Type:         discordgo.ApplicationCommandOptionString,
+					Required:     true,
+					Autocomplete: true,
+				},
+				{
+					Name:        "count",
+					Description: "Number of copies (default: all)",
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					MinValue:    &minCopiesOption,
+					MaxValue:    maxCopiesOption,
+					Required:    false,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "show",
+			Description: "Show the deck being built",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "finish",
+			Description: "Share the deck and its code in the channel",
+		},
+	},
+}
+
+func DeckBuild(
+	builds repository.DeckBuildRepository,
+	cards repository.CardRepository,
+	names searchFunc,
+	resolve resolveFunc,
+	localize localizeFunc) *discord.SlashCommand {
+	return discord.NewCommand(deckBuildCommand, deckBuildCommandHandler(builds, cards, names, resolve, localize))
+}
+
+func deckBuildCommandHandler(
+	builds repository.DeckBuildRepository,
+	cards repository.CardRepository,
+	names searchFunc,
+	resolve resolveFunc,
+	localize localizeFunc) discord.Handler {
+	load := deck.BuildLoadCards(cards.FindCards)
+
+	return func(s discord.Session, i *discordgo.InteractionCreate) error {
+		ctx := context.Background()
+		user := discord.UserID(i)
+		sub := i.ApplicationCommandData().Options[0]
+		prefs := resolve(ctx, i, repository.Preferences{})
+		language := prefs.Language
+
+		switch i.Type {
+		case discordgo.InteractionApplicationCommandAutocomplete:
+			return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+				Data: &discordgo.InteractionResponseData{
+					Choices: deckBuildAutocomplete(ctx, builds, cards, names, user, language, sub),
+				},
+			})
+		case discordgo.InteractionApplicationCommand:
+		default:
+			return nil
+		}
+
+		show := func(content string, build []repository.DeckBuildCard) error {
+			embed, err := deckBuildEmbed(ctx, load, language, localize, build)
+			if err != nil {
+				log.Error().Err(err).Str("user", user).Msg("failed to show deck build")
+				return respondEphemeral(s, i, "Failed to show the deck")
+			}
+			embed.Title = "Deck being built"
+			return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Flags:   discordgo.MessageFlagsEphemeral,
+					Content: content,
+					Embeds:  []*discordgo.MessageEmbed{embed},
+				},
+			})
+		}
+
+		switch sub.Name {
+		case "start":
+			code := strings.TrimSpace(option.GetOrElse(sub.Options, "code", ""))
+			var started []repository.DeckBuildCard
+			if code != "" {
+				d, err := deck.Decode(code)
+				if err != nil {
+					return respondEphemeral(s, i, fmt.Sprintf("**%s** is a invalid code", code))
+				}
+				for _, de := range d {
+					started = append(started, repository.DeckBuildCard{CardCode: de.Card.CardCode, Count: int(de.Count)})
+				}
+			}
+
+			err := builds.UpdateDeckBuild(ctx, user, func(b *repository.DeckBuild) {
+				b.Cards = started
+			})
+			if err != nil {
+				log.Error().Err(err).Str("user", user).Msg("failed to start deck build")
+				return respondEphemeral(s, i, "Failed to start the deck")
+			}
+			return show("Started a new deck, add cards with `/deckbuild add`.", started)
+		case "add", "remove":
+			c, err := findChosenCard(ctx, cards, names, language, option.GetOrElse(sub.Options, "card", ""))
+			if err != nil {
+				log.Error().Err(err).Str("user", user).Msg("failed to find card")
+				return respondEphemeral(s, i, "Failed to find the card")
+			}
+			if c == nil {
+				return respondEphemeral(s, i, "Unknown card, choose one of the suggestions")
+			}
+			if sub.Name == "add" && !c.Collectible {
+				return respondEphemeral(s, i, fmt.Sprintf("**%s** can't be put in decks", c.Name))
+			}
+
+			var build []repository.DeckBuildCard
+			changed := 0
+			err = builds.UpdateDeckBuild(ctx, user, func(b *repository.DeckBuild) {
+				if sub.Name == "add" {
+					b.Cards, changed = addDeckBuildCards(b.Cards, c.CardCode, int(option.GetOrElse(sub.Options, "count", 1.0)))
+				} else {
+					b.Cards, changed = removeDeckBuildCards(b.Cards, c.CardCode, int(option.GetOrElse(sub.Options, "count", 0.0)))
+				}
+				build = b.Cards
+			})
+			if err != nil {
+				log.Error().Err(err).Str("user", user).Str("subcommand", sub.Name).Msg("failed to change deck build")
+				return respondEphemeral(s, i, "Failed to save the deck")
+			}
+
+			switch {
+			case sub.Name == "remove" && changed == 0:
+				return show(fmt.Sprintf("The deck has no **%s**.", c.Name), build)
+			case sub.Name == "remove":
+				return show(fmt.Sprintf("Removed %d × **%s**.", changed, c.Name), build)
+			case changed == 0 && deckBuildSize(build) >= deck.Size:
+				return show(fmt.Sprintf("The deck already has %d cards.", deck.Size), build)
+			case changed == 0:
+				return show(fmt.Sprintf("The deck already has %d copies of **%s**.", deck.MaxCopies, c.Name), build)
+			default:
+				return show(fmt.Sprintf("Added %d × **%s**.", changed, c.Name), build)
+			}
+		case "show":
+			build, err := builds.DeckBuild(ctx, user)
+			if err != nil {
+				log.Error().Err(err).Str("user", user).Msg("failed to load deck build")
+				return respondEphemeral(s, i, "Failed to load the deck")
+			}
+			return show("", build.Cards)
+		case "finish":
+			build, err := builds.DeckBuild(ctx, user)
+			if err != nil {
+				log.Error().Err(err).Str("user", user).Msg("failed to load deck build")
+				return respondEphemeral(s, i, "Failed to load the deck")
+			}
+			if len(build.Cards) == 0 {
+				return respondEphemeral(s, i, "The deck has no cards, add some with `/deckbuild add`")
+			}
+
+			code, err := deck.Encode(deckBuildDeck(build.Cards))
+			if err != nil {
+				log.Error().Err(err).Str("user", user).Msg("failed to encode deck build")
+				return respondEphemeral(s, i, "Failed to encode the deck")
+			}
+			embed, err := deckBuildEmbed(ctx, load, language, localize, build.Cards)
+			if err != nil {
+				log.Error().Err(err).Str("user", user).Msg("failed to show deck build")
+				return respondEphemeral(s, i, "Failed to show the deck")
+			}
+			embed.Title = code
+			embed.Footer = memberFooter(i)
+
+			err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Embeds:     []*discordgo.MessageEmbed{embed},
+					Components: deckLink(prefs, code),
+				},
+			})
+			if err != nil {
+				return err
+			}
+
+			err = builds.UpdateDeckBuild(ctx, user, func(b *repository.DeckBuild) {
+				b.Cards = nil
+			})
+			if err != nil {
+				log.Error().Err(err).Str("user", user).Msg("failed to clear deck build")
+			}
+			return nil
+		}
+		return nil
+	}
+}
+
+// deckBuildAutocomplete suggests collectible cards when adding cards and the
+// cards of the deck when removing them.
+func deckBuildAutocomplete(ctx context.Context, builds repository.DeckBuildRepository, cards repository.CardRepository, names searchFunc, user string, language string, sub *discordgo.ApplicationCommandInteractionDataOption) []*discordgo.ApplicationCommandOptionChoice {
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	typed := option.GetOrElse(sub.Options, "card", "")
+	switch sub.Name {
+	case "add":
+		results, err := names(ctx, language, "", typed)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to search cards")
+		}
+		for _, r := range results {
+			if r.Card.Collectible {
+				choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: choiceName(r), Value: r.Card.CardCode})
+			}
+		}
+	case "remove":
+		build, err := builds.DeckBuild(ctx, user)
+		if err != nil {
+			log.Error().Err(err).Str("user", user).Msg("failed to load deck build")
+			return choices
+		}
+		codes := lo.Map(build.Cards, func(c repository.DeckBuildCard, _ int) string {
+			return c.CardCode
+		})
+		found, err := cards.FindCards(ctx, language, "", codes...)
+		if err != nil {
+			log.Error().Err(err).Str("user", user).Msg("failed to find the cards of deck build")
+			return choices
+		}
+
+		typed = search.Fold(strings.TrimSpace(typed))
+		sort.Slice(found, func(i, j int) bool {
+			return found[i].Name < found[j].Name
+		})
+		for _, c := range found {
+			if len(choices) == maxChoices {
+				break
+			}
+			if strings.Contains(search.Fold(c.Name), typed) {
+				choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: c.Name, Value: c.CardCode})
+			}
+		}
+	}
+	return choices
+}
+
+// addDeckBuildCards adds up to count copies of a card, so the deck has at
+// most deck.MaxCopies copies of it and deck.Size cards. It returns the number
+// of copies added.
+func addDeckBuildCards(cards []repository.DeckBuildCard, code string, count int) ([]repository.DeckBuildCard, int) {
+	count = max(min(count, deck.Size-deckBuildSize(cards)), 0)
+	for i, c := range cards {
+		if c.CardCode == code {
+			added := max(min(count, deck.MaxCopies-c.Count), 0)
+			cards[i].Count += added
+			return cards, added
+		}
+	}
+	count = min(count, deck.MaxCopies)
+	if count == 0 {
+		return cards, 0
+	}
+	return append(cards, repository.DeckBuildCard{CardCode: code, Count: count}), count
+}
+
+// removeDeckBuildCards removes up to count copies of a card, or all of them if
+// count is 0, and the card once it has no copies left. It returns the number
+// of copies removed.
+func removeDeckBuildCards(cards []repository.DeckBuildCard, code string, count int) ([]repository.DeckBuildCard, int) {
+	for i, c := range cards {
+		if c.CardCode != code {
+			continue
+		}
+		if count <= 0 || count >= c.Count {
+			return append(cards[:i], cards[i+1:]...), c.Count
+		}
+		cards[i].Count -= count
+		return cards, count
+	}
+	return cards, 0
+}
+
+// deckBuildSize returns the number of cards of a deck being built.
+func deckBuildSize(cards []repository.DeckBuildCard) int {
+	return lo.SumBy(cards, func(c repository.DeckBuildCard) int {
+		return c.Count
+	})
+}
+
+// deckBuildDeck returns the deck of the cards of a deck being built, with only
+// their codes.
+func deckBuildDeck(cards []repository.DeckBuildCard) deck.Deck {
+	return lo.Map(cards, func(c repository.DeckBuildCard, _ int) deck.DeckEntry {
+		return deck.DeckEntry{Count: uint64(c.Count), Card: &repository.Card{CardCode: c.CardCode}}
+	})
+}
+
+// deckBuildEmbed lists the cards of a deck being built with their counts, the
+// code of the deck and its legality.
+func deckBuildEmbed(ctx context.Context, load loadCardsFunc, language string, localize localizeFunc, cards []repository.DeckBuildCard) (*discordgo.MessageEmbed, error) {
+	if len(cards) == 0 {
+		return &discordgo.MessageEmbed{Description: "No cards yet, add some with `/deckbuild add`"}, nil
+	}
+
+	d := deckBuildDeck(cards)
+	code, err := deck.Encode(d)
+	if err != nil {
+		return nil, err
+	}
+	loaded, err := load(ctx, language, "", d)
+	if err != nil {
+		return nil, err
+	}
+
+	champions := lo.SumBy(loaded, func(de deck.DeckEntry) int {
+		if card.IsChampion(de.Card) {
+			return int(de.Count)
+		}
+		return 0
+	})
+	return &discordgo.MessageEmbed{
+		Description: fmt.Sprintf("**%d**/%d cards · **%d** champions\n`%s`\n%s",
+			deckBuildSize(cards), deck.Size, champions, code, legalityDescription(deck.Validate(loaded), language, localize)),
+		Fields: deckFields(loaded, language, localize),
+	}, nil
+}
@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dneto/sai-scout/internal/deck"
+	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/dneto/sai-scout/internal/search"
+	"github.com/dneto/sai-scout/pkg/discord"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DeckBuildCommand", func() {
+	var (
+		ctx      = context.Background()
+		repo     *repository.Memory
+		handler  discord.Handler
+		response *discordgo.InteractionResponse
+		session  discord.Session = fakeSession{
+			interactionRespond: func(i *discordgo.Interaction, ir *discordgo.InteractionResponse, opts ...discordgo.RequestOption) error {
+				response = ir
+				return nil
+			},
+		}
+	)
+
+	run := func(typ discordgo.InteractionType, sub string, options ...*discordgo.ApplicationCommandInteractionDataOption) {
+		GinkgoHelper()
+		Expect(handler(session, subCommand(typ, "deckbuild", sub, options...))).To(Succeed())
+	}
+
+	count := func(n int) *discordgo.ApplicationCommandInteractionDataOption {
+		return &discordgo.ApplicationCommandInteractionDataOption{Name: "count", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(n)}
+	}
+
+	build := func() []repository.DeckBuildCard {
+		GinkgoHelper()
+		b, err := repo.DeckBuild(ctx, "user")
+		Expect(err).ToNot(HaveOccurred())
+		return b.Cards
+	}
+
+	BeforeEach(func() {
+		repo = repository.NewMemory()
+		Expect(repo.SaveBundle(ctx, &repository.SetBundle{
+			Set: "set1", Locale: "en_us", Version: "4.9.0", LastModified: time.Now(),
+			Cards: []*repository.Card{
+				{CardCode: "01PZ040", Name: "Twisted Fate", Cost: 4, Type: "Unit", TypeRef: "Unit", RarityRef: "Champion", Collectible: true},
+				{CardCode: "01IO001", Name: "Jhin", Cost: 5, Type: "Unit", TypeRef: "Unit", RarityRef: "Champion", Collectible: true},
+				{CardCode: "01NX002", Name: "Blade's Edge", Cost: 1, Type: "Spell", TypeRef: "Spell", RarityRef: "Common", Collectible: true},
+				{CardCode: "01IO001T1", Name: "Jhin's Lotus", Cost: 0, Type: "Spell", TypeRef: "Spell"},
+			},
+		}, false)).To(Succeed())
+		names := search.NewCards(repo)
+		handler = deckBuildCommandHandler(repo, names, names.SearchAllLocales, fakeResolve, func(_ string, id string) string { return id })
+	})
+
+	It("adds cards and shows the running list", func() {
+		run(discordgo.InteractionApplicationCommand, "add", stringOption("card", "01PZ040"), count(2))
+		Expect(response.Data.Content).To(Equal("Added 2 × **Twisted Fate**."))
+		Expect(response.Data.Flags).To(Equal(discordgo.MessageFlagsEphemeral))
+
+		run(discordgo.InteractionApplicationCommand, "add", stringOption("card", "blade"))
+		Expect(response.Data.Content).To(Equal("Added 1 × **Blade's Edge**."))
+		Expect(build()).To(Equal([]repository.DeckBuildCard{{CardCode: "01PZ040", Count: 2}, {CardCode: "01NX002", Count: 1}}))
+
+		embed := response.Data.Embeds[0]
+		Expect(embed.Description).To(HavePrefix("**3**/40 cards · **2** champions\n"))
+		Expect(embed.Fields).To(HaveLen(2))
+		Expect(embed.Fields[0].Name).To(Equal("Champions"))
+		Expect(embed.Fields[1].Name).To(Equal("Spells"))
+	})
+
+	It("keeps at most 3 copies of a card", func() {
+		run(discordgo.InteractionApplicationCommand, "add", stringOption("card", "01PZ040"), count(2))
+		run(discordgo.InteractionApplicationCommand, "add", stringOption("card", "01PZ040"), count(3))
+		Expect(response.Data.Content).To(Equal("Added 1 × **Twisted Fate**."))
+
+		run(discordgo.InteractionApplicationCommand, "add", stringOption("card", "01PZ040"))
+		Expect(response.Data.Content).To(Equal("The deck already has 3 copies of **Twisted Fate**."))
+		Expect(build()).To(Equal([]repository.DeckBuildCard{{CardCode: "01PZ040", Count: 3}}))
+	})
+
+	It("keeps at most 40 cards", func() {
+		Expect(repo.UpdateDeckBuild(ctx, "user", func(b *repository.DeckBuild) {
+			b.Cards = []repository.DeckBuildCard{{CardCode: "01IO001", Count: 39}}
+		})).To(Succeed())
+
+		run(discordgo.InteractionApplicationCommand, "add", stringOption("card", "01PZ040"), count(3))
+		Expect(response.Data.Content).To(Equal("Added 1 × **Twisted Fate**."))
+		run(discordgo.InteractionApplicationCommand, "add", stringOption("card", "01NX002"))
+		Expect(response.Data.Content).To(Equal("The deck already has 40 cards."))
+	})
+
+	It("rejects cards that can't be put in decks", func() {
+		run(discordgo.InteractionApplicationCommand, "add", stringOption("card", "01IO001T1"))
+		Expect(response.Data.Content).To(Equal("**Jhin's Lotus** can't be put in decks"))
+		Expect(build()).To(BeEmpty())
+	})
+
+	It("removes cards", func() {
+		run(discordgo.InteractionApplicationCommand, "add", stringOption("card", "01PZ040"), count(3))
+		run(discordgo.InteractionApplicationCommand, "remove", stringOption("card", "01PZ040"), count(1))
+		Expect(response.Data.Content).To(Equal("Removed 1 × **Twisted Fate**."))
+		Expect(build()).To(Equal([]repository.DeckBuildCard{{CardCode: "01PZ040", Count: 2}}))
+
+		run(discordgo.InteractionApplicationCommand, "remove", stringOption("card", "01PZ040"))
+		Expect(response.Data.Content).To(Equal("Removed 2 × **Twisted Fate**."))
+		Expect(build()).To(BeEmpty())
+
+		run(discordgo.InteractionApplicationCommand, "remove", stringOption("card", "01PZ040"))
+		Expect(response.Data.Content).To(Equal("The deck has no **Twisted Fate**."))
+	})
+
+	It("suggests collectible cards to add and the cards of the deck to remove", func() {
+		run(discordgo.InteractionApplicationCommandAutocomplete, "add", stringOption("card", "jhin"))
+		Expect(response.Data.Choices).To(Equal([]*discordgo.ApplicationCommandOptionChoice{{Name: "Jhin", Value: "01IO001"}}))
+
+		run(discordgo.InteractionApplicationCommand, "add", stringOption("card", "01PZ040"))
+		run(discordgo.InteractionApplicationCommand, "add", stringOption("card", "01IO001"))
+		run(discordgo.InteractionApplicationCommandAutocomplete, "remove", stringOption("card", ""))
+		Expect(response.Data.Choices).To(Equal([]*discordgo.ApplicationCommandOptionChoice{
+			{Name: "Jhin", Value: "01IO001"},
+			{Name: "Twisted Fate", Value: "01PZ040"},
+		}))
+		run(discordgo.InteractionApplicationCommandAutocomplete, "remove", stringOption("card", "twi"))
+		Expect(response.Data.Choices).To(Equal([]*discordgo.ApplicationCommandOptionChoice{{Name: "Twisted Fate", Value: "01PZ040"}}))
+	})
+
+	It("starts decks from codes and resumes them", func() {
+		code, err := deck.Encode(deck.Deck{
+			{Count: 3, Card: &repository.Card{CardCode: "01PZ040"}},
+			{Count: 2, Card: &repository.Card{CardCode: "01NX002"}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		run(discordgo.InteractionApplicationCommand, "start", stringOption("code", code))
+		Expect(build()).To(ConsistOf(
+			repository.DeckBuildCard{CardCode: "01PZ040", Count: 3},
+			repository.DeckBuildCard{CardCode: "01NX002", Count: 2},
+		))
+
+		run(discordgo.InteractionApplicationCommand, "show")
+		Expect(response.Data.Embeds[0].Description).To(HavePrefix("**5**/40 cards · **3** champions\n`" + code + "`\n❌ Standard · ❌ Eternal\n"))
+
+		run(discordgo.InteractionApplicationCommand, "start", stringOption("code", "not a code"))
+		Expect(response.Data.Content).To(Equal("**not a code** is a invalid code"))
+		Expect(build()).To(HaveLen(2))
+
+		run(discordgo.InteractionApplicationCommand, "start")
+		Expect(build()).To(BeEmpty())
+	})
+
+	It("shares the code when finished and clears the deck", func() {
+		run(discordgo.InteractionApplicationCommand, "finish")
+		Expect(response.Data.Content).To(Equal("The deck has no cards, add some with `/deckbuild add`"))
+
+		run(discordgo.InteractionApplicationCommand, "add", stringOption("card", "01PZ040"), count(3))
+		run(discordgo.InteractionApplicationCommand, "finish")
+		Expect(response.Type).To(Equal(discordgo.InteractionResponseChannelMessageWithSource))
+		Expect(response.Data.Flags).To(BeZero())
+
+		code := response.Data.Embeds[0].Title
+		Expect(deck.Decode(code)).To(Equal(deck.Deck{{Count: 3, Card: &repository.Card{CardCode: "01PZ040"}}}))
+		Expect(response.Data.Components).To(HaveLen(1))
+		Expect(build()).To(BeEmpty())
+	})
+})
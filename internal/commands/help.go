@@ -20,6 +20,11 @@ var HelpCommand = discord.NewCommand(
 				Name:        "info",
 				Description: "Show help for info command",
 			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "patchnotes",
+				Description: "Show help for patchnotes command",
+			},
 			{
 				Type:        discordgo.ApplicationCommandOptionSubCommand,
 				Name:        "invite",
@@ -58,6 +63,19 @@ func helpCommandHandler(s discord.Session, in *discordgo.InteractionCreate) erro
 			},
 		})
 
+	case "patchnotes":
+		return s.InteractionRespond(in.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Flags: discordgo.MessageFlagsEphemeral,
+				Content: "> **/patchnotes** `from` `to`: Shows the cards added, removed and changed between two patches" + "\n" +
+					"> " + "\n" +
+					"> _Options:_" + "\n" +
+					"> • `from`: The older patch. Example: 4.9.0" + "\n" +
+					"> • `to`: The newer patch. Example: 4.10.0",
+			},
+		})
+
 	case "invite":
 		return s.InteractionRespond(in.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -12,9 +12,9 @@ import (
 	"text/template"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/dneto/sai-scout/internal/i18n"
 	"github.com/dneto/sai-scout/internal/regions"
 	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/dneto/sai-scout/internal/search"
 	"github.com/dneto/sai-scout/pkg/discord"
 	"github.com/dneto/sai-scout/pkg/discord/embed"
 	"github.com/dneto/sai-scout/pkg/discord/option"
@@ -39,32 +39,35 @@ var infoCommand = &discordgo.ApplicationCommand{
 			Choices:     i18nToOptions(),
 			Required:    false,
 		},
+		patchOption,
 	},
 }
 
 var Info = func(
-	findByCodes findByCodesFunc,
-	matchName matchNameFunc,
-	localize localizeFunc,
-	findLang getLangFunc) *discord.SlashCommand {
+	cards repository.CardRepository,
+	names searchFunc,
+	guilds repository.GuildConfigRepository,
+	resolve resolveFunc,
+	localize localizeFunc) *discord.SlashCommand {
 	lfunc := func(l string) func(string) string {
 		return func(s string) string {
 			return localize(l, s)
 		}
 	}
-	return discord.NewCommand(infoCommand, infoCommandHandler(findByCodes, matchName, lfunc, findLang))
+	return discord.NewCommand(infoCommand, infoCommandHandler(cards, names, guilds, resolve, lfunc))
 }
 
 func infoCommandHandler(
-	findByCodes findByCodesFunc,
-	matchName matchNameFunc,
-	localizeBuilder localizeBuildFunc,
-	findLang getLangFunc) discord.Handler {
+	cardRepo repository.CardRepository,
+	names searchFunc,
+	guilds repository.GuildConfigRepository,
+	resolve resolveFunc,
+	localizeBuilder localizeBuildFunc) discord.Handler {
 
 	return func(s discord.Session, in *discordgo.InteractionCreate) error {
 		switch in.Type {
 		case discordgo.InteractionApplicationCommandAutocomplete:
-			return s.InteractionRespond(in.Interaction, infoAutocompleteHandler(matchName, findLang, in))
+			return s.InteractionRespond(in.Interaction, infoAutocompleteHandler(cardRepo, names, guilds, resolve, in))
 		case discordgo.InteractionApplicationCommand:
 			s.InteractionRespond(in.Interaction, &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
@@ -80,14 +83,16 @@ func infoCommandHandler(
 				return discord.ErrorResponse(s, in, errors.New("missing name"))
 			}
 
-			defaultLang, _ := findLang(ctx, in.GuildID)
-			if defaultLang == "" {
-				defaultLang = string(i18n.Default)
-			}
-			language := option.GetOrElse(options, "language", defaultLang)
+			language := resolve(ctx, in, repository.Preferences{
+				Language: option.GetOrElse(options, "language", ""),
+			}).Language
+			patch := option.GetOrElse(options, "patch", "")
 			localize := localizeBuilder(language)
 
-			cs, err := findByCodes(ctx, language, cardName)
+			cs, err := cardRepo.FindCards(ctx, language, patch, cardName)
+			if errors.Is(err, repository.ErrInvalidVersion) {
+				return discord.ErrorResponse(s, in, errors.New("invalid patch: "+patch))
+			}
 			if err != nil || len(cs) < 1 {
 				return discord.ErrorResponse(s, in, errors.New("invalid code: "+cardName))
 			}
@@ -95,7 +100,7 @@ func infoCommandHandler(
 			c := cs[0]
 			cards := []*repository.Card{}
 			if c.SupertypeRef == "Champion" && c.TypeRef == "Unit" {
-				associated, err := findByCodes(ctx, language, c.AssociatedCardRefs...)
+				associated, err := cardRepo.FindCards(ctx, language, patch, c.AssociatedCardRefs...)
 				if err != nil {
 					log.Println(err)
 				}
@@ -129,17 +134,16 @@ func cardToEmbed(localize func(string) string) func(c *repository.Card, _ int) *
 	return func(c *repository.Card, _ int) *discordgo.MessageEmbed {
 		me := &discordgo.MessageEmbed{
 			Description: buildTitle(c),
-			Image: &discordgo.MessageEmbedImage{
-				URL: c.Assets[0].FullAbsolutePath,
-			},
-			Thumbnail: &discordgo.MessageEmbedThumbnail{
-				URL: c.Assets[0].GameAbsolutePath,
-			},
 			Footer: &discordgo.MessageEmbedFooter{
 				Text: "🎨 " + c.ArtistName,
 			},
 		}
 
+		if len(c.Assets) > 0 {
+			me.Image = &discordgo.MessageEmbedImage{URL: c.Assets[0].FullAbsolutePath}
+			me.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: c.Assets[0].GameAbsolutePath}
+		}
+
 		addFields := embed.AddFields(me)
 
 		addFields(embed.InlineField(localize("Type"), c.Type))
@@ -184,28 +188,46 @@ func cardToEmbed(localize func(string) string) func(c *repository.Card, _ int) *
 	}
 }
 
-func infoAutocompleteHandler(matchName matchNameFunc, findLang getLangFunc, in *discordgo.InteractionCreate) *discordgo.InteractionResponse {
+// infoAutocompleteHandler suggests the cards of the guild aliases matching the
+// name first, and then the cards found by name.
+func infoAutocompleteHandler(
+	cardRepo repository.CardRepository,
+	names searchFunc,
+	guilds repository.GuildConfigRepository,
+	resolve resolveFunc,
+	in *discordgo.InteractionCreate) *discordgo.InteractionResponse {
 	data := in.ApplicationCommandData()
 	o := data.Options
 
 	name, _ := option.Get[string](o, "name")
-	defaultLang, _ := findLang(context.Background(), in.GuildID)
-	if defaultLang == "" {
-		defaultLang = string(i18n.Default)
-	}
-	language := option.GetOrElse(o, "language", defaultLang)
+	language := resolve(context.Background(), in, repository.Preferences{
+		Language: option.GetOrElse(o, "language", ""),
+	}).Language
+	patch := option.GetOrElse(o, "patch", "")
+
+	ch := aliasChoices(context.Background(), guilds, cardRepo, in.GuildID, language, patch, name)
 
-	choices, err := matchName(context.Background(), language, name)
+	choices, err := names(context.Background(), language, patch, name)
 	if err != nil {
 		log.Println(err)
 	}
 
-	ch := make([]*discordgo.ApplicationCommandOptionChoice, len(choices))
-	for i, c := range choices {
-		ch[i] = &discordgo.ApplicationCommandOptionChoice{
-			Name:  c.Name,
-			Value: c.CardCode,
+	seen := make(map[string]bool, len(ch))
+	for _, c := range ch {
+		seen[c.Name] = true
+	}
+	for _, c := range choices {
+		if len(ch) == maxChoices {
+			break
+		}
+		choice := &discordgo.ApplicationCommandOptionChoice{
+			Name:  choiceName(c),
+			Value: c.Card.CardCode,
 		}
+		if seen[choice.Name] {
+			continue
+		}
+		ch = append(ch, choice)
 	}
 
 	return &discordgo.InteractionResponse{
@@ -216,6 +238,20 @@ func infoAutocompleteHandler(matchName matchNameFunc, findLang getLangFunc, in *
 	}
 }
 
+// choiceName is the card name in the output language, followed by the
+// name that matched in brackets when it was found in another language.
+func choiceName(r search.Result) string {
+	name := r.Card.Name
+	if r.Matched != "" && !strings.EqualFold(r.Matched, name) {
+		name = fmt.Sprintf("%s [%s]", name, r.Matched)
+	}
+	// Discord rejects choice names longer than 100 characters.
+	if runes := []rune(name); len(runes) > 100 {
+		name = string(runes[:100])
+	}
+	return name
+}
+
 func buildTitle(c *repository.Card) string {
 	cardTemplate := "{{.regions}}{{.cost}} **{{.name}}**"
 	tmpl, err := template.New("").Parse(cardTemplate)
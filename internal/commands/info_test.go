@@ -6,6 +6,7 @@ import (
 	"github.com/bwmarrin/discordgo"
 	"github.com/dneto/sai-scout/internal/i18n"
 	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/dneto/sai-scout/internal/search"
 	"github.com/dneto/sai-scout/pkg/discord"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -25,7 +26,7 @@ var _ = Describe("InfoCommand", func() {
 		)
 
 		BeforeEach(func() {
-			command = Info(nil, nil, nil)
+			command = Info(nil, nil, nil, nil, localize)
 		})
 
 		It("should have name 'info'", func() {
@@ -39,12 +40,12 @@ var _ = Describe("InfoCommand", func() {
 
 	Context("autocomplete", func() {
 		var (
-			resp *discordgo.InteractionResponse
+			resp    *discordgo.InteractionResponse
+			results []search.Result
 		)
-		BeforeEach(func() {
-
-			matchName := func(ctx context.Context, language string, name string) ([]*repository.Card, error) {
-				return []*repository.Card{annie}, nil
+		JustBeforeEach(func() {
+			names := func(ctx context.Context, language string, version string, name string) ([]search.Result, error) {
+				return results, nil
 			}
 
 			interaction := &discordgo.InteractionCreate{
@@ -60,7 +61,11 @@ var _ = Describe("InfoCommand", func() {
 					},
 				},
 			}
-			resp = infoAutocompleteHandler(matchName, interaction)
+			resp = infoAutocompleteHandler(nil, names, nil, fakeResolve, interaction)
+		})
+
+		BeforeEach(func() {
+			results = []search.Result{{Card: annie, Matched: "annie"}}
 		})
 
 		It("should show all cards returned from match function", func() {
@@ -73,11 +78,23 @@ var _ = Describe("InfoCommand", func() {
 				},
 			}))
 		})
+
+		Context("when the name matched in another language", func() {
+			BeforeEach(func() {
+				results = []search.Result{{Card: annie, Matched: "Anita"}}
+			})
+
+			It("shows the matched name in brackets", func() {
+				Expect(resp.Data.Choices).To(Equal([]*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Annie [Anita]", Value: "ANNIE"},
+				}))
+			})
+		})
 	})
 
 	Context("handler", func() {
 		var (
-			handler             discord.HandlerFunc
+			handler             discord.Handler
 			interactionResponse *discordgo.InteractionResponse
 			followUpResp        *discordgo.WebhookParams
 			err                 error
@@ -95,7 +112,7 @@ var _ = Describe("InfoCommand", func() {
 
 		Context("champion card", func() {
 			BeforeEach(func() {
-				cardByCode := findByCodesFunc(func(ctx context.Context, language string, cardCodes ...string) ([]*repository.Card, error) {
+				cardByCode := fakeCards{findCards: func(ctx context.Context, language string, version string, cardCodes ...string) ([]*repository.Card, error) {
 					for _, c := range cardCodes {
 						switch c {
 						case "ANNIE":
@@ -107,9 +124,9 @@ var _ = Describe("InfoCommand", func() {
 						}
 					}
 					return nil, nil
-				})
+				}}
 
-				handler = infoCommandHandler(cardByCode, nil, localizeBuildF)
+				handler = infoCommandHandler(cardByCode, nil, nil, fakeResolve, localizeBuildF)
 				interaction := &discordgo.InteractionCreate{
 					Interaction: &discordgo.Interaction{
 						Type: discordgo.InteractionApplicationCommand,
@@ -192,11 +209,11 @@ var _ = Describe("InfoCommand", func() {
 
 		Context("spell card", func() {
 			BeforeEach(func() {
-				cardByCode := findByCodesFunc(func(ctx context.Context, language string, cardCodes ...string) ([]*repository.Card, error) {
+				cardByCode := fakeCards{findCards: func(ctx context.Context, language string, version string, cardCodes ...string) ([]*repository.Card, error) {
 					return []*repository.Card{bladesEdge}, nil
-				})
+				}}
 
-				handler = infoCommandHandler(cardByCode, nil, localizeBuildF)
+				handler = infoCommandHandler(cardByCode, nil, nil, fakeResolve, localizeBuildF)
 
 				interaction := &discordgo.InteractionCreate{
 					Interaction: &discordgo.Interaction{
@@ -257,11 +274,11 @@ var _ = Describe("InfoCommand", func() {
 
 		Context("unit card", func() {
 			BeforeEach(func() {
-				cardByCode := findByCodesFunc(func(ctx context.Context, language string, cardCodes ...string) ([]*repository.Card, error) {
+				cardByCode := fakeCards{findCards: func(ctx context.Context, language string, version string, cardCodes ...string) ([]*repository.Card, error) {
 					return []*repository.Card{crimsonPigeon}, nil
-				})
+				}}
 
-				handler = infoCommandHandler(cardByCode, nil, localizeBuildF)
+				handler = infoCommandHandler(cardByCode, nil, nil, fakeResolve, localizeBuildF)
 				interaction := &discordgo.InteractionCreate{
 					Interaction: &discordgo.Interaction{
 						Type: discordgo.InteractionApplicationCommand,
@@ -0,0 +1,179 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dneto/sai-scout/internal/patchnotes"
+	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/dneto/sai-scout/pkg/discord"
+	"github.com/dneto/sai-scout/pkg/discord/embed"
+	"github.com/dneto/sai-scout/pkg/discord/option"
+	"github.com/rs/zerolog/log"
+)
+
+var patchNotesCommand = &discordgo.ApplicationCommand{
+	Name:        "patchnotes",
+	Description: "Show the cards added, removed and changed between two patches",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Name:        "from",
+			Description: "Older game patch, e.g. 4.9.0",
+			Type:        discordgo.ApplicationCommandOptionString,
+			Required:    true,
+		},
+		{
+			Name:        "to",
+			Description: "Newer game patch, e.g. 4.10.0",
+			Type:        discordgo.ApplicationCommandOptionString,
+			Required:    true,
+		},
+		{
+			Name:        "language",
+			Description: "Language",
+			Type:        discordgo.ApplicationCommandOptionString,
+			Choices:     i18nToOptions(),
+			Required:    false,
+		},
+	},
+}
+
+func PatchNotes(cards repository.CardRepository, resolve resolveFunc, localize localizeFunc) *discord.SlashCommand {
+	paginator := discord.NewPaginator(patchNotesCommand.Name, pagesTTL)
+	return discord.NewCommand(patchNotesCommand, paginator.Wrap(patchNotesCommandHandler(cards, resolve, localize, paginator)))
+}
+
+func patchNotesCommandHandler(cardRepo repository.CardRepository, resolve resolveFunc, localize localizeFunc, paginator *discord.Paginator) discord.Handler {
+	return func(s discord.Session, in *discordgo.InteractionCreate) error {
+		s.InteractionRespond(in.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Processing...",
+			},
+		})
+
+		ctx := context.Background()
+		options := in.ApplicationCommandData().Options
+		from := option.GetOrElse(options, "from", "")
+		to := option.GetOrElse(options, "to", "")
+		for _, patch := range []string{from, to} {
+			if err := repository.ValidateVersion(patch); err != nil {
+				return discord.ErrorResponse(s, in, fmt.Errorf("**%s** is not a known patch", patch))
+			}
+		}
+		if repository.CompareVersions(from, to) >= 0 {
+			return discord.ErrorResponse(s, in, fmt.Errorf("**%s** must be older than **%s**", from, to))
+		}
+
+		language := resolve(ctx, in, repository.Preferences{
+			Language: option.GetOrElse(options, "language", ""),
+		}).Language
+
+		snapshots := make([][]*repository.Card, 2)
+		for i, patch := range []string{from, to} {
+			cards, err := cardRepo.FindSnapshot(ctx, language, patch)
+			if errors.Is(err, repository.ErrInvalidVersion) || errors.Is(err, repository.ErrVersionNotFound) {
+				return discord.ErrorResponse(s, in, fmt.Errorf("**%s** is not a known patch", patch))
+			}
+			if err != nil {
+				log.Err(err).Str("patch", patch).Str("language", language).Msg("failed to find cards")
+				return discord.ErrorResponse(s, in, errors.New("failed to load patch notes"))
+			}
+			snapshots[i] = cards
+		}
+
+		notes := patchnotes.Diff(snapshots[0], snapshots[1])
+		title := fmt.Sprintf("%s → %s", from, to)
+		if notes.Empty() {
+			_, err := s.FollowupMessageCreate(in.Interaction, false, &discordgo.WebhookParams{
+				Embeds: []*discordgo.MessageEmbed{{Title: title, Description: "No card changes"}},
+			})
+			return err
+		}
+
+		pages := embed.Paginate(title, patchNotesFields(notes, func(s string) string {
+			return localize(language, s)
+		}))
+		for i, p := range pages {
+			p.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("%d/%d", i+1, len(pages))}
+		}
+
+		if err := paginator.Send(s, in, pages); err != nil {
+			log.Error().Err(err).Msg("failed to send patch notes followup message")
+			return err
+		}
+		return nil
+	}
+}
+
+func patchNotesFields(notes patchnotes.Notes, localize func(string) string) []*discordgo.MessageEmbedField {
+	var fields []*discordgo.MessageEmbedField
+	cardList := func(title string, cards []*repository.Card) {
+		lines := make([]string, len(cards))
+		for i, c := range cards {
+			lines[i] = buildTitle(c)
+		}
+		for i, value := range chunkLines(lines, embed.MaxFieldValue) {
+			if i > 0 {
+				title = "ㅤ"
+			}
+			fields = append(fields, embed.Field(title, value))
+		}
+	}
+
+	cardList(localize("New Cards"), notes.Added)
+	cardList(localize("Removed Cards"), notes.Removed)
+
+	for _, cc := range notes.Changed {
+		lines := make([]string, len(cc.Changes))
+		for i, change := range cc.Changes {
+			lines[i] = fmt.Sprintf("**%s**: %s → %s",
+				localize(string(change.Field)),
+				changeValue(change.Field, change.From),
+				changeValue(change.Field, change.To))
+		}
+		fields = append(fields, embed.Field(
+			embed.Truncate(cc.Card.Name, 256),
+			embed.Truncate(strings.Join(lines, "\n"), embed.MaxFieldValue),
+		))
+	}
+
+	return fields
+}
+
+func changeValue(field patchnotes.Field, value string) string {
+	switch {
+	case field == patchnotes.Collectible && value == "true":
+		return "✅"
+	case field == patchnotes.Collectible:
+		return "❌"
+	case value == "":
+		return "—"
+	}
+	return value
+}
+
+// chunkLines joins lines into chunks of at most max characters, truncating
+// lines that don't fit alone.
+func chunkLines(lines []string, max int) []string {
+	var chunks []string
+	chunk := ""
+	for _, line := range lines {
+		line = embed.Truncate(line, max)
+		if chunk != "" && len([]rune(chunk))+1+len([]rune(line)) > max {
+			chunks = append(chunks, chunk)
+			chunk = ""
+		}
+		if chunk != "" {
+			chunk += "\n"
+		}
+		chunk += line
+	}
+	if chunk != "" {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/dneto/sai-scout/pkg/discord"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PatchNotesCommand", func() {
+	var (
+		followUp *discordgo.WebhookParams
+		session  discord.Session = fakeSession{
+			interactionRespond: func(i *discordgo.Interaction, ir *discordgo.InteractionResponse, opts ...discordgo.RequestOption) error {
+				return nil
+			},
+			followUpMessageCreate: func(i *discordgo.Interaction, waitResponse bool, params *discordgo.WebhookParams, opts ...discordgo.RequestOption) (*discordgo.Message, error) {
+				followUp = params
+				return nil, nil
+			},
+		}
+		cards = fakeCards{
+			findSnapshot: func(ctx context.Context, language string, version string) ([]*repository.Card, error) {
+				return nil, nil
+			},
+		}
+	)
+
+	run := func(from, to string) {
+		GinkgoHelper()
+		paginator := discord.NewPaginator(patchNotesCommand.Name, pagesTTL)
+		Expect(patchNotesCommandHandler(cards, fakeResolve, func(_, id string) string { return id }, paginator)(session, &discordgo.InteractionCreate{
+			Interaction: &discordgo.Interaction{
+				Type: discordgo.InteractionApplicationCommand,
+				Data: discordgo.ApplicationCommandInteractionData{
+					Name: "patchnotes",
+					Options: []*discordgo.ApplicationCommandInteractionDataOption{
+						stringOption("from", from),
+						stringOption("to", to),
+					},
+				},
+			},
+		})).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		followUp = nil
+	})
+
+	It("rejects an unknown patch before comparing the versions", func() {
+		run("4.10.0", "foo")
+		Expect(followUp.Embeds[0].Description).To(Equal("**foo** is not a known patch"))
+	})
+
+	It("rejects patches that are not in order", func() {
+		run("4.10.0", "4.9.0")
+		Expect(followUp.Embeds[0].Description).To(Equal("**4.10.0** must be older than **4.9.0**"))
+	})
+
+	It("answers when no card changed", func() {
+		run("4.9.0", "4.10.0")
+		Expect(followUp.Embeds[0].Description).To(Equal("No card changes"))
+	})
+})
@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dneto/sai-scout/internal/query"
+	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/dneto/sai-scout/pkg/discord"
+	"github.com/dneto/sai-scout/pkg/discord/embed"
+	"github.com/dneto/sai-scout/pkg/discord/option"
+	"github.com/rs/zerolog/log"
+)
+
+// searchPageSize is the number of cards in each page of search results.
+const searchPageSize = 10
+
+var searchCommand = &discordgo.ApplicationCommand{
+	Name:        "search",
+	Description: "Find cards by cost, region, type, keyword, text...",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Name:        "query",
+			Description: `Like cost<=2 region:Noxus type:Spell keyword:Burst text:"deal 1"`,
+			Type:        discordgo.ApplicationCommandOptionString,
+			Required:    true,
+		},
+		{
+			Name:        "language",
+			Description: "Language",
+			Type:        discordgo.ApplicationCommandOptionString,
+			Choices:     i18nToOptions(),
+			Required:    false,
+		},
+		patchOption,
+	},
+}
+
+func Search(filter filterFunc, resolve resolveFunc) *discord.SlashCommand {
+	paginator := discord.NewPaginator(searchCommand.Name, pagesTTL)
+	return discord.NewCommand(searchCommand, paginator.Wrap(searchCommandHandler(filter, resolve, paginator)))
+}
+
+func searchCommandHandler(filter filterFunc, resolve resolveFunc, paginator *discord.Paginator) discord.Handler {
+	return func(s discord.Session, in *discordgo.InteractionCreate) error {
+		s.InteractionRespond(in.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Processing...",
+			},
+		})
+
+		ctx := context.Background()
+		options := in.ApplicationCommandData().Options
+		q := option.GetOrElse(options, "query", "")
+		expr, err := query.Parse(q)
+		if err != nil {
+			return discord.ErrorResponse(s, in, fmt.Errorf("invalid query, %w", err))
+		}
+
+		language := resolve(ctx, in, repository.Preferences{
+			Language: option.GetOrElse(options, "language", ""),
+		}).Language
+		patch := option.GetOrElse(options, "patch", "")
+
+		cards, err := filter(ctx, language, patch, expr.Match)
+		if errors.Is(err, repository.ErrInvalidVersion) {
+			return discord.ErrorResponse(s, in, errors.New("invalid patch: "+patch))
+		}
+		if err != nil {
+			log.Error().Err(err).Str("query", q).Msg("failed to search cards")
+			return discord.ErrorResponse(s, in, errors.New("failed to search cards"))
+		}
+
+		return paginator.Send(s, in, searchPages(q, sortSearchResults(cards)))
+	}
+}
+
+// sortSearchResults sorts cards with collectible cards first, then by cost
+// and name.
+func sortSearchResults(cards []*repository.Card) []*repository.Card {
+	sort.SliceStable(cards, func(i, j int) bool {
+		a, b := cards[i], cards[j]
+		if a.Collectible != b.Collectible {
+			return a.Collectible
+		}
+		if a.Cost != b.Cost {
+			return a.Cost < b.Cost
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.CardCode < b.CardCode
+	})
+	return cards
+}
+
+// searchPages shows the cards found, searchPageSize cards per page.
+func searchPages(q string, cards []*repository.Card) []*discordgo.MessageEmbed {
+	title := embed.Truncate(q, 256)
+	if len(cards) == 0 {
+		return []*discordgo.MessageEmbed{{Title: title, Description: "No cards found"}}
+	}
+
+	count := (len(cards) + searchPageSize - 1) / searchPageSize
+	pages := make([]*discordgo.MessageEmbed, count)
+	for i := range pages {
+		start := i * searchPageSize
+		lines := make([]string, 0, searchPageSize)
+		for _, c := range cards[start:min(start+searchPageSize, len(cards))] {
+			lines = append(lines, fmt.Sprintf("%s `%s`", buildTitle(c), c.CardCode))
+		}
+		pages[i] = &discordgo.MessageEmbed{
+			Title:       title,
+			Description: strings.Join(lines, "\n"),
+			Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("%d/%d · %d cards", i+1, count, len(cards))},
+		}
+	}
+	return pages
+}
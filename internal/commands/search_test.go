@@ -0,0 +1,158 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/dneto/sai-scout/pkg/discord"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SearchCommand", func() {
+	var (
+		cards     []*repository.Card
+		paginator *discord.Paginator
+		response  *discordgo.InteractionResponse
+		followUp  *discordgo.WebhookParams
+		session   discord.Session = fakeSession{
+			interactionRespond: func(i *discordgo.Interaction, ir *discordgo.InteractionResponse, opts ...discordgo.RequestOption) error {
+				response = ir
+				return nil
+			},
+			followUpMessageCreate: func(i *discordgo.Interaction, waitResponse bool, params *discordgo.WebhookParams, opts ...discordgo.RequestOption) (*discordgo.Message, error) {
+				followUp = params
+				return nil, nil
+			},
+		}
+	)
+
+	filter := func(ctx context.Context, language string, version string, match func(*repository.Card) bool) ([]*repository.Card, error) {
+		var found []*repository.Card
+		for _, c := range cards {
+			if match(c) {
+				found = append(found, c)
+			}
+		}
+		return found, nil
+	}
+
+	run := func(options ...*discordgo.ApplicationCommandInteractionDataOption) {
+		GinkgoHelper()
+		Expect(paginator.Wrap(searchCommandHandler(filter, fakeResolve, paginator))(session, &discordgo.InteractionCreate{
+			Interaction: &discordgo.Interaction{
+				Type:   discordgo.InteractionApplicationCommand,
+				Member: &discordgo.Member{User: &discordgo.User{ID: "user"}},
+				Data:   discordgo.ApplicationCommandInteractionData{Name: "search", Options: options},
+			},
+		})).To(Succeed())
+	}
+
+	buttons := func(components []discordgo.MessageComponent) []discordgo.Button {
+		GinkgoHelper()
+		Expect(components).To(HaveLen(1))
+		var buttons []discordgo.Button
+		for _, c := range components[0].(discordgo.ActionsRow).Components {
+			buttons = append(buttons, c.(discordgo.Button))
+		}
+		return buttons
+	}
+
+	click := func(user string, button discordgo.Button) {
+		GinkgoHelper()
+		Expect(paginator.Wrap(searchCommandHandler(filter, fakeResolve, paginator))(session, &discordgo.InteractionCreate{
+			Interaction: &discordgo.Interaction{
+				Type:    discordgo.InteractionMessageComponent,
+				Member:  &discordgo.Member{User: &discordgo.User{ID: user}},
+				Data:    discordgo.MessageComponentInteractionData{CustomID: button.CustomID},
+				Message: &discordgo.Message{Embeds: followUp.Embeds},
+			},
+		})).To(Succeed())
+	}
+
+	queryOption := func(q string) *discordgo.ApplicationCommandInteractionDataOption {
+		return &discordgo.ApplicationCommandInteractionDataOption{Name: "query", Type: discordgo.ApplicationCommandOptionString, Value: q}
+	}
+
+	BeforeEach(func() {
+		paginator = discord.NewPaginator("search", time.Minute)
+		cards = []*repository.Card{
+			{CardCode: "B", Name: "Blade's Edge", Cost: 1, TypeRef: "Spell", Collectible: true},
+			{CardCode: "A", Name: "Annie", Cost: 1, TypeRef: "Unit", Collectible: true},
+			{CardCode: "T", Name: "Tibbers", Cost: 0, TypeRef: "Unit"},
+		}
+	})
+
+	It("lists the cards matching the query", func() {
+		run(queryOption("type:Unit"))
+		Expect(followUp.Embeds).To(HaveLen(1))
+		Expect(followUp.Embeds[0].Description).To(Equal(
+			fmt.Sprintf("%s `A`\n%s `T`", buildTitle(cards[1]), buildTitle(cards[2]))))
+		Expect(followUp.Embeds[0].Footer.Text).To(Equal("1/1 · 2 cards"))
+		Expect(followUp.Components).To(BeEmpty())
+	})
+
+	Context("with many results", func() {
+		BeforeEach(func() {
+			cards = nil
+			for i := 0; i < 25; i++ {
+				cards = append(cards, &repository.Card{CardCode: fmt.Sprintf("%02d", i), Name: fmt.Sprintf("Card %02d", i), Cost: i})
+			}
+			run(queryOption("card"))
+		})
+
+		It("shows the first page with buttons", func() {
+			Expect(followUp.Embeds).To(HaveLen(1))
+			Expect(followUp.Embeds[0].Footer.Text).To(Equal("1/3 · 25 cards"))
+			b := buttons(followUp.Components)
+			Expect(b).To(HaveLen(4))
+			Expect([]bool{b[0].Disabled, b[1].Disabled, b[2].Disabled, b[3].Disabled}).To(Equal([]bool{true, true, false, false}))
+		})
+
+		It("changes pages with the buttons", func() {
+			b := buttons(followUp.Components)
+			click("user", b[2])
+			Expect(response.Type).To(Equal(discordgo.InteractionResponseUpdateMessage))
+			Expect(response.Data.Embeds[0].Footer.Text).To(Equal("2/3 · 25 cards"))
+			Expect(response.Data.Embeds[0].Description).To(HavePrefix(buildTitle(cards[10])))
+
+			click("user", b[3])
+			Expect(response.Data.Embeds[0].Footer.Text).To(Equal("3/3 · 25 cards"))
+			last := buttons(response.Data.Components)
+			Expect([]bool{last[0].Disabled, last[1].Disabled, last[2].Disabled, last[3].Disabled}).To(Equal([]bool{false, false, true, true}))
+
+			click("user", b[1])
+			Expect(response.Data.Embeds[0].Footer.Text).To(Equal("2/3 · 25 cards"))
+			click("user", b[0])
+			Expect(response.Data.Embeds[0].Footer.Text).To(Equal("1/3 · 25 cards"))
+		})
+
+		It("only lets the user who searched change pages", func() {
+			click("other", buttons(followUp.Components)[2])
+			Expect(response.Type).To(Equal(discordgo.InteractionResponseChannelMessageWithSource))
+			Expect(response.Data.Flags).To(Equal(discordgo.MessageFlagsEphemeral))
+		})
+
+		It("removes the buttons of expired pages", func() {
+			paginator = discord.NewPaginator("search", -time.Minute)
+			run(queryOption("card"))
+			click("user", buttons(followUp.Components)[2])
+			Expect(response.Type).To(Equal(discordgo.InteractionResponseUpdateMessage))
+			Expect(response.Data.Components).To(BeEmpty())
+			Expect(response.Data.Embeds[0].Footer.Text).To(Equal("1/3 · 25 cards"))
+		})
+	})
+
+	It("reports invalid queries", func() {
+		run(queryOption("cost<=two"))
+		Expect(followUp.Embeds[0].Description).To(Equal("invalid query, position 7: cost must be a number"))
+	})
+
+	It("reports when nothing is found", func() {
+		run(queryOption("cost>9"))
+		Expect(followUp.Embeds[0].Description).To(Equal("No cards found"))
+	})
+})
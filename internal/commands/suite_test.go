@@ -1,9 +1,11 @@
 package commands
 
 import (
+	"context"
 	"testing"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/dneto/sai-scout/internal/prefs"
 	"github.com/dneto/sai-scout/internal/repository"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -118,3 +120,50 @@ func (s fakeSession) FollowupMessageCreate(i *discordgo.Interaction, waitRespons
 func (s fakeSession) InteractionRespond(i *discordgo.Interaction, ir *discordgo.InteractionResponse, opts ...discordgo.RequestOption) error {
 	return s.interactionRespond(i, ir, opts...)
 }
+
+type fakeCards struct {
+	findCards    func(ctx context.Context, language string, version string, codes ...string) ([]*repository.Card, error)
+	searchByName func(ctx context.Context, language string, version string, name string) ([]*repository.Card, error)
+	findSnapshot func(ctx context.Context, language string, version string) ([]*repository.Card, error)
+}
+
+func (f fakeCards) FindCards(ctx context.Context, language string, version string, codes ...string) ([]*repository.Card, error) {
+	return f.findCards(ctx, language, version, codes...)
+}
+
+func (f fakeCards) SearchByName(ctx context.Context, language string, version string, name string) ([]*repository.Card, error) {
+	return f.searchByName(ctx, language, version, name)
+}
+
+func (f fakeCards) FindSnapshot(ctx context.Context, language string, version string) ([]*repository.Card, error) {
+	return f.findSnapshot(ctx, language, version)
+}
+
+// fakeResolve resolves the settings of users, channels and guilds that never
+// changed them.
+func fakeResolve(_ context.Context, _ *discordgo.InteractionCreate, explicit repository.Preferences) repository.Preferences {
+	return prefs.Merge(explicit, prefs.Default)
+}
+
+// stringOption returns a string option as Discord sends it.
+func stringOption(name string, value string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionString, Value: value}
+}
+
+// subCommand returns an interaction that runs the sub command of command with
+// options, sent by the user "user" from the guild "guild".
+func subCommand(typ discordgo.InteractionType, command string, sub string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			Type:    typ,
+			GuildID: "guild",
+			Member:  &discordgo.Member{User: &discordgo.User{ID: "user"}},
+			Data: discordgo.ApplicationCommandInteractionData{
+				Name: command,
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{
+					{Name: sub, Type: discordgo.ApplicationCommandOptionSubCommand, Options: options},
+				},
+			},
+		},
+	}
+}
@@ -2,18 +2,27 @@ package commands
 
 import (
 	"context"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/dneto/sai-scout/internal/deck"
 	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/dneto/sai-scout/internal/search"
 )
 
-type findByCodesFunc func(ctx context.Context, language string, cardCodes ...string) ([]*repository.Card, error)
-type matchNameFunc func(ctx context.Context, language string, name string) ([]*repository.Card, error)
-type decodeFunc func(ctx context.Context, language string, code string) (deck.Deck, error)
+type decodeFunc func(ctx context.Context, language string, version string, code string) (deck.Deck, error)
+type loadCardsFunc func(ctx context.Context, language string, version string, d deck.Deck) (deck.Deck, error)
+type searchFunc func(ctx context.Context, language string, version string, name string) ([]search.Result, error)
+type filterFunc func(ctx context.Context, language string, version string, match func(*repository.Card) bool) ([]*repository.Card, error)
 type localizeFunc func(language string, messageID string) string
 type localizeBuildFunc func(string) func(string) string
 
+// resolveFunc returns the settings of an interaction, the explicit ones
+// being the options of the command.
+type resolveFunc func(ctx context.Context, in *discordgo.InteractionCreate, explicit repository.Preferences) repository.Preferences
+
+// pagesTTL is how long users can change the pages of the results of a
+// command.
+const pagesTTL = 15 * time.Minute
+
 type interactionHandler func(s *discordgo.Session, in *discordgo.InteractionCreate) (*discordgo.InteractionResponse, error)
-type getLangFunc func(ctx context.Context, guildID string) (string, error)
-type getTemplateFunc func(ctx context.Context, guildID string) (string, string, error)
@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/dneto/sai-scout/pkg/discord"
+	"github.com/dneto/sai-scout/pkg/discord/option"
+	"github.com/rs/zerolog/log"
+)
+
+var prefsCommand = &discordgo.ApplicationCommand{
+	Name:        "prefs",
+	Description: "Manage your preferences, which apply in every server",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "language",
+			Description: "Set your language output",
+			Options:     []*discordgo.ApplicationCommandOption{languageValueOption},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "website",
+			Description: "Set your website to view decks",
+			Options:     websiteOptions,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "show",
+			Description: "Show your preferences",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "clear",
+			Description: "Use the server and channel configuration again",
+		},
+	},
+}
+
+func Prefs(users repository.UserPrefsRepository) *discord.SlashCommand {
+	return discord.NewCommand(prefsCommand, prefsCommandHandler(users))
+}
+
+func prefsCommandHandler(users repository.UserPrefsRepository) discord.Handler {
+	return func(s discord.Session, i *discordgo.InteractionCreate) error {
+		ctx := context.Background()
+		user := discord.UserID(i)
+		sub := i.ApplicationCommandData().Options[0]
+
+		var update func(*repository.Preferences)
+		switch sub.Name {
+		case "language":
+			language := option.GetOrElse(sub.Options, "value", "")
+			update = func(p *repository.Preferences) {
+				p.Language = language
+			}
+		case "website":
+			template, label := option.GetOrElse(sub.Options, "template", ""), option.GetOrElse(sub.Options, "name", "")
+			if !validURLTemplate(template) {
+				return respondEphemeral(s, i, "Malformed URL")
+			}
+			update = func(p *repository.Preferences) {
+				p.URLTemplate, p.URLLabel = template, label
+			}
+		case "clear":
+			update = func(p *repository.Preferences) {
+				*p = repository.Preferences{}
+			}
+		case "show":
+			p, err := users.UserPrefs(ctx, user)
+			if err != nil {
+				log.Error().Err(err).Str("user", user).Msg("failed to load user preferences")
+				return respondEphemeral(s, i, "Failed to load your preferences")
+			}
+			return respondEphemeral(s, i, prefsSummary(p))
+		default:
+			return nil
+		}
+
+		if err := users.UpdateUserPrefs(ctx, user, update); err != nil {
+			log.Error().Err(err).Str("user", user).Msg("failed to update user preferences")
+			return respondEphemeral(s, i, "Failed to save your preferences")
+		}
+		return respondEphemeral(s, i, "Done!")
+	}
+}
+
+// prefsSummary describes the preferences of a user. Settings not chosen are
+// left to the channel and the server.
+func prefsSummary(p *repository.Preferences) string {
+	language, website := "server default", "server default"
+	if p.Language != "" {
+		language = p.Language
+	}
+	if p.URLTemplate != "" {
+		website = fmt.Sprintf("%s (`%s`)", p.URLLabel, p.URLTemplate)
+	}
+	return fmt.Sprintf("**Language:** %s\n**Website:** %s", language, website)
+}
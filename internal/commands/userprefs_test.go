@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/dneto/sai-scout/pkg/discord"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PrefsCommand", func() {
+	var (
+		repo     *repository.Memory
+		handler  discord.Handler
+		response *discordgo.InteractionResponse
+		session  discord.Session = fakeSession{
+			interactionRespond: func(i *discordgo.Interaction, ir *discordgo.InteractionResponse, opts ...discordgo.RequestOption) error {
+				response = ir
+				return nil
+			},
+		}
+	)
+
+	run := func(sub string, options ...*discordgo.ApplicationCommandInteractionDataOption) {
+		GinkgoHelper()
+		Expect(handler(session, subCommand(discordgo.InteractionApplicationCommand, "prefs", sub, options...))).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		repo = repository.NewMemory()
+		handler = prefsCommandHandler(repo)
+	})
+
+	It("saves the language of the user", func() {
+		run("language", stringOption("value", "pt_br"))
+		Expect(response.Data.Content).To(Equal("Done!"))
+		Expect(repo.UserPrefs(context.Background(), "user")).To(Equal(&repository.Preferences{Language: "pt_br"}))
+	})
+
+	It("saves the website of the user", func() {
+		run("website", stringOption("name", "Example"), stringOption("template", "https://example.com/{{code}}"))
+		Expect(repo.UserPrefs(context.Background(), "user")).To(Equal(&repository.Preferences{
+			URLTemplate: "https://example.com/{{code}}",
+			URLLabel:    "Example",
+		}))
+	})
+
+	It("rejects malformed websites", func() {
+		run("website", stringOption("template", "example.com/{{code}}"), stringOption("name", "Example"))
+		Expect(response.Data.Content).To(Equal("Malformed URL"))
+		Expect(repo.UserPrefs(context.Background(), "user")).To(Equal(&repository.Preferences{}))
+	})
+
+	It("shows and clears the preferences", func() {
+		run("language", stringOption("value", "pt_br"))
+		run("show")
+		Expect(response.Data.Content).To(ContainSubstring("pt_br"))
+
+		run("clear")
+		Expect(repo.UserPrefs(context.Background(), "user")).To(Equal(&repository.Preferences{}))
+	})
+})
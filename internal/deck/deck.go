@@ -20,16 +20,28 @@ type DeckEntry struct {
 
 type Deck []DeckEntry
 
-type loadCardsInfoByCodeFunc func(ctx context.Context, language string, codes ...string) ([]*repository.Card, error)
-
-func BuildLoadDeckInfo(loadCardsInfo loadCardsInfoByCodeFunc) func(context.Context, string, string) (Deck, error) {
-	return func(ctx context.Context, language string, code string) (Deck, error) {
-		deck, err := decode(code)
+type loadCardsInfoByCodeFunc func(ctx context.Context, language string, version string, codes ...string) ([]*repository.Card, error)
+
+// BuildLoadDeckInfo returns a function that decodes a deck code and loads its
+// cards as they were in the given version. An empty version loads the current
+// cards.
+func BuildLoadDeckInfo(loadCardsInfo loadCardsInfoByCodeFunc) func(context.Context, string, string, string) (Deck, error) {
+	load := BuildLoadCards(loadCardsInfo)
+	return func(ctx context.Context, language string, version string, code string) (Deck, error) {
+		deck, err := Decode(code)
 		if err != nil {
 			return nil, err
 		}
+		return load(ctx, language, version, deck)
+	}
+}
 
-		cardsInfo, err := loadCardsInfo(ctx, language, codesFromDeck(deck)...)
+// BuildLoadCards returns a function that loads the cards of a deck, known by
+// their codes, as they were in the given version. Cards not found are left
+// out and the others are sorted by cost and name.
+func BuildLoadCards(loadCardsInfo loadCardsInfoByCodeFunc) func(context.Context, string, string, Deck) (Deck, error) {
+	return func(ctx context.Context, language string, version string, deck Deck) (Deck, error) {
+		cardsInfo, err := loadCardsInfo(ctx, language, version, codesFromDeck(deck)...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to find cards: %w", err)
 		}
@@ -50,7 +62,8 @@ func BuildLoadDeckInfo(loadCardsInfo loadCardsInfoByCodeFunc) func(context.Conte
 	}
 }
 
-func decode(code string) (Deck, error) {
+// Decode returns the cards of a deck code, with only their codes.
+func Decode(code string) (Deck, error) {
 	deck, err := lordeckcode.Decode(code)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode deck: %w", err)
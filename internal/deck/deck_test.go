@@ -24,12 +24,12 @@ var _ = Describe("Decks", func() {
 			target deck.Deck
 			err    error
 
-			findByCodeFunc func(ctx context.Context, language string, codes ...string) ([]*repository.Card, error)
+			findByCodeFunc func(ctx context.Context, language string, version string, codes ...string) ([]*repository.Card, error)
 		)
 
 		Context("no errors", func() {
 			BeforeEach(func() {
-				findByCodeFunc = func(_ context.Context, _ string, codes ...string) ([]*repository.Card, error) {
+				findByCodeFunc = func(_ context.Context, _ string, _ string, codes ...string) ([]*repository.Card, error) {
 					cards := make([]*repository.Card, 0)
 					for _, code := range codes {
 						card, found := cardByCode[code]
@@ -41,7 +41,7 @@ var _ = Describe("Decks", func() {
 					return cards, nil
 				}
 				deckCode := "CEAAAAICAYBQYHA"
-				target, err = deck.BuildLoadDeckInfo(findByCodeFunc)(ctx, string(i18n.Default), deckCode)
+				target, err = deck.BuildLoadDeckInfo(findByCodeFunc)(ctx, string(i18n.Default), "", deckCode)
 
 			})
 
@@ -57,13 +57,30 @@ var _ = Describe("Decks", func() {
 			})
 		})
 
+		Context("with version", func() {
+			var version string
+			BeforeEach(func() {
+				findByCodeFunc = func(_ context.Context, _ string, v string, codes ...string) ([]*repository.Card, error) {
+					version = v
+					return []*repository.Card{annie, ravenbloomConservatory}, nil
+				}
+				deckCode := "CEAAAAICAYBQYHA"
+				target, err = deck.BuildLoadDeckInfo(findByCodeFunc)(ctx, string(i18n.Default), "4.9.0", deckCode)
+			})
+
+			It("loads the cards of the version", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(version).To(Equal("4.9.0"))
+			})
+		})
+
 		Context("find errors", func() {
 			BeforeEach(func() {
-				findByCodeFunc = func(_ context.Context, _ string, codes ...string) ([]*repository.Card, error) {
+				findByCodeFunc = func(_ context.Context, _ string, _ string, codes ...string) ([]*repository.Card, error) {
 					return nil, errors.New("fatal error")
 				}
 				deckCode := "CEAAAAICAYBQYHA"
-				target, err = deck.BuildLoadDeckInfo(findByCodeFunc)(ctx, string(i18n.Default), deckCode)
+				target, err = deck.BuildLoadDeckInfo(findByCodeFunc)(ctx, string(i18n.Default), "", deckCode)
 
 			})
 
@@ -78,11 +95,11 @@ var _ = Describe("Decks", func() {
 
 		Context("decode errors", func() {
 			BeforeEach(func() {
-				findByCodeFunc = func(_ context.Context, _ string, codes ...string) ([]*repository.Card, error) {
+				findByCodeFunc = func(_ context.Context, _ string, _ string, codes ...string) ([]*repository.Card, error) {
 					return nil, errors.New("fatal error")
 				}
 				deckCode := ""
-				target, err = deck.BuildLoadDeckInfo(findByCodeFunc)(ctx, string(i18n.Default), deckCode)
+				target, err = deck.BuildLoadDeckInfo(findByCodeFunc)(ctx, string(i18n.Default), "", deckCode)
 
 			})
 
@@ -0,0 +1,49 @@
+// Package decktest holds the decks shared by the specs of the deck and
+// commands packages.
+package decktest
+
+import (
+	"fmt"
+
+	"github.com/dneto/sai-scout/internal/deck"
+	"github.com/dneto/sai-scout/internal/repository"
+)
+
+// Follower returns a common unit from regions, legal in every format and
+// named after its code.
+func Follower(code string, regions ...string) *repository.Card {
+	return &repository.Card{
+		CardCode:   code,
+		Name:       code,
+		RarityRef:  "Common",
+		TypeRef:    "Unit",
+		RegionRefs: regions,
+		FormatRefs: []string{deck.Standard.Ref(), deck.Eternal.Ref()},
+	}
+}
+
+// Champion returns a champion from regions, legal in every format and named
+// after its code.
+func Champion(code string, regions ...string) *repository.Card {
+	c := Follower(code, regions...)
+	c.RarityRef = "Champion"
+	return c
+}
+
+// Legal returns a Noxus and Demacia deck of 40 cards with 6 champions. The
+// champions come first, followed by 17 followers coded 01XX000 to 01XX016
+// with 2 copies each. Every call returns new cards.
+func Legal() deck.Deck {
+	d := deck.Deck{
+		{Count: 3, Card: Champion("01NX038", "Noxus")},
+		{Count: 3, Card: Champion("01DE012", "Demacia")},
+	}
+	for i := 0; i < 17; i++ {
+		region := "Noxus"
+		if i%2 == 0 {
+			region = "Demacia"
+		}
+		d = append(d, deck.DeckEntry{Count: 2, Card: Follower(fmt.Sprintf("01XX%03d", i), region)})
+	}
+	return d
+}
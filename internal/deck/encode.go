@@ -0,0 +1,136 @@
+package deck
+
+import (
+	"cmp"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/dneto/sai-scout/internal/regions"
+)
+
+// format is the deck code format written in the first byte of codes.
+const format = 1
+
+// regionVersions are the deck code versions which introduced each region.
+// Codes are written with the lowest version which knows all of their
+// regions, so older decoders still read them.
+var regionVersions = map[regions.Region]byte{
+	regions.Demacia:      1,
+	regions.Freljord:     1,
+	regions.Ionia:        1,
+	regions.Noxus:        1,
+	regions.PiltoverZaun: 1,
+	regions.ShadowIsles:  1,
+	regions.Bilgewater:   2,
+	regions.Targon:       2,
+	regions.Shurima:      3,
+	regions.BandleCity:   4,
+	regions.Runeterra:    5,
+}
+
+var cardCodePattern = regexp.MustCompile(`^(\d{2})([A-Z]{2})(\d{3})$`)
+
+// codeCard is a card of a deck as written in deck codes.
+type codeCard struct {
+	code   string
+	count  uint64
+	set    uint64
+	region regions.Region
+	number uint64
+}
+
+// Encode returns the deck code of a deck. Cards are sorted by code and their
+// groups by size and first card, so decks with the same cards always have the
+// same code whatever their order. Copies of the same card are added up.
+func Encode(d Deck) (string, error) {
+	counts := make(map[string]uint64)
+	for _, de := range d {
+		counts[de.Card.CardCode] += de.Count
+	}
+
+	version := byte(1)
+	var cards []codeCard
+	for code, count := range counts {
+		if count == 0 {
+			continue
+		}
+		m := cardCodePattern.FindStringSubmatch(code)
+		if m == nil {
+			return "", fmt.Errorf("invalid card code %q", code)
+		}
+		region := *regions.FromShort(m[2])
+		regionVersion, ok := regionVersions[region]
+		if !ok {
+			return "", fmt.Errorf("unknown region of card %q", code)
+		}
+		set, _ := strconv.ParseUint(m[1], 10, 64)
+		number, _ := strconv.ParseUint(m[3], 10, 64)
+		cards = append(cards, codeCard{code: code, count: count, set: set, region: region, number: number})
+		version = max(version, regionVersion)
+	}
+	sort.Slice(cards, func(i, j int) bool {
+		return cards[i].code < cards[j].code
+	})
+
+	b := []byte{format<<4 | version}
+	for count := uint64(3); count > 0; count-- {
+		groups := groupBySetAndRegion(cards, count)
+		b = binary.AppendUvarint(b, uint64(len(groups)))
+		for _, g := range groups {
+			b = binary.AppendUvarint(b, uint64(len(g)))
+			b = binary.AppendUvarint(b, g[0].set)
+			b = binary.AppendUvarint(b, uint64(g[0].region))
+			for _, c := range g {
+				b = binary.AppendUvarint(b, c.number)
+			}
+		}
+	}
+	for _, c := range cards {
+		if c.count <= 3 {
+			continue
+		}
+		b = binary.AppendUvarint(b, c.count)
+		b = binary.AppendUvarint(b, c.set)
+		b = binary.AppendUvarint(b, uint64(c.region))
+		b = binary.AppendUvarint(b, c.number)
+	}
+
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b), nil
+}
+
+// groupBySetAndRegion groups the cards with count copies by set and region.
+// Groups are sorted by size and then by their first card, and their cards by
+// code.
+func groupBySetAndRegion(cards []codeCard, count uint64) [][]codeCard {
+	type key struct {
+		set    uint64
+		region regions.Region
+	}
+	var groups [][]codeCard
+	index := make(map[key]int)
+	for _, c := range cards {
+		if c.count != count {
+			continue
+		}
+		k := key{set: c.set, region: c.region}
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], c)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if c := cmp.Compare(len(groups[i]), len(groups[j])); c != 0 {
+			return c < 0
+		}
+		return groups[i][0].code < groups[j][0].code
+	})
+	return groups
+}
@@ -0,0 +1,71 @@
+package deck_test
+
+import (
+	"github.com/dneto/sai-scout/internal/deck"
+	"github.com/dneto/sai-scout/internal/repository"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Encode", func() {
+	entry := func(code string, count uint64) deck.DeckEntry {
+		return deck.DeckEntry{Count: count, Card: &repository.Card{CardCode: code}}
+	}
+
+	DescribeTable("encodes decoded decks back to their code",
+		func(code string) {
+			d, err := deck.Decode(code)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deck.Encode(d)).To(Equal(code))
+		},
+		Entry("a small deck", "CEAAAAICAYBQYHA"),
+		Entry("a full deck", "CEDACAIFAEAQMAJJAEEAABQCA4CQCAQDAEAQWKRUAMEACAICBQBQCAIFB4AQMBJAAEDQKCQBAEAQCFA"),
+	)
+
+	It("keeps the cards of codes written by other encoders", func() {
+		d, err := deck.Decode("CEBAIAIFB4WDANQIAEAQGDAUDAQSIJZUAIAQCBIFAEAQCBAA")
+		Expect(err).ToNot(HaveOccurred())
+		code, err := deck.Encode(d)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deck.Decode(code)).To(ConsistOf(d))
+	})
+
+	It("returns the same code whatever the order of the cards", func() {
+		a, err := deck.Encode(deck.Deck{entry("01DE001", 3), entry("01NX002", 1), entry("01DE002", 2)})
+		Expect(err).ToNot(HaveOccurred())
+		b, err := deck.Encode(deck.Deck{entry("01DE002", 2), entry("01DE001", 3), entry("01NX002", 1)})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(a).To(Equal(b))
+	})
+
+	It("adds up copies of the same card", func() {
+		code, err := deck.Encode(deck.Deck{entry("01DE001", 1), entry("01DE001", 2)})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deck.Decode(code)).To(Equal(deck.Deck{entry("01DE001", 3)}))
+	})
+
+	It("encodes cards with more than 3 copies", func() {
+		code, err := deck.Encode(deck.Deck{entry("01DE001", 6), entry("01DE002", 1)})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deck.Decode(code)).To(Equal(deck.Deck{entry("01DE002", 1), entry("01DE001", 6)}))
+	})
+
+	It("uses the version of the newest region", func() {
+		code, err := deck.Encode(deck.Deck{entry("01DE001", 1)})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(code).To(HavePrefix("CE"))
+
+		code, err = deck.Encode(deck.Deck{entry("01DE001", 1), entry("06RU002", 1)})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(code).To(HavePrefix("CU"))
+		Expect(deck.Decode(code)).To(ConsistOf(entry("01DE001", 1), entry("06RU002", 1)))
+	})
+
+	It("rejects invalid card codes", func() {
+		_, err := deck.Encode(deck.Deck{entry("Annie", 1)})
+		Expect(err).To(MatchError(`invalid card code "Annie"`))
+
+		_, err = deck.Encode(deck.Deck{entry("01XY001", 1)})
+		Expect(err).To(MatchError(`unknown region of card "01XY001"`))
+	})
+})
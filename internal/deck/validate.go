@@ -0,0 +1,212 @@
+package deck
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/dneto/sai-scout/internal/card"
+)
+
+// The deck building rules of the official formats.
+const (
+	// Size is the number of cards of a deck.
+	Size = 40
+	// MaxCopies is the number of copies of a card a deck can have.
+	MaxCopies = 3
+	// MaxChampions is the number of champion cards a deck can have.
+	MaxChampions = 6
+	// MaxRegions is the number of regions the cards of a deck can be from.
+	MaxRegions = 2
+)
+
+// runeterraRef is the region ref of Runeterra champions, which count as a
+// region of their own.
+const runeterraRef = "Runeterra"
+
+// Format is an official game format.
+type Format string
+
+const (
+	Standard Format = "Standard"
+	Eternal  Format = "Eternal"
+)
+
+// Formats are the official formats decks are validated for.
+var Formats = []Format{Standard, Eternal}
+
+// Ref returns the format ref of the cards legal in f.
+func (f Format) Ref() string {
+	return "client_Formats_" + string(f) + "_name"
+}
+
+// Rule is a deck building rule of the official formats.
+type Rule int
+
+const (
+	// SizeRule is that decks have Size cards.
+	SizeRule Rule = iota
+	// CopiesRule is that decks have up to MaxCopies copies of a card.
+	CopiesRule
+	// ChampionsRule is that decks have up to MaxChampions champions.
+	ChampionsRule
+	// RegionsRule is that the cards of decks are from up to MaxRegions
+	// regions.
+	RegionsRule
+)
+
+// Violation is a rule a deck breaks.
+type Violation struct {
+	Rule Rule
+	// Count is the number of cards, copies of Card, champions or regions of
+	// the deck.
+	Count int
+	// Limit is the number the rule allows.
+	Limit int
+	// Card is the name of the card with too many copies.
+	Card string
+	// Regions are the regions of a deck from too many regions.
+	Regions []string
+}
+
+// Legality is whether a deck can be played in the official formats.
+type Legality struct {
+	// Violations are the rules the deck breaks in every format.
+	Violations []Violation
+	// Illegal are the names of the cards of the deck not legal in each
+	// format.
+	Illegal map[Format][]string
+}
+
+// Legal returns whether the deck can be played in a format.
+func (l Legality) Legal(f Format) bool {
+	return len(l.Violations) == 0 && len(l.Illegal[f]) == 0
+}
+
+// Validate checks a deck against the rules of the official formats. Cards
+// must be loaded: the rules depend on their rarity, regions and formats.
+//
+// Cards from several regions count as being from any of them, and each
+// Runeterra champion counts as a region of its own.
+func Validate(d Deck) Legality {
+	l := Legality{Illegal: make(map[Format][]string)}
+
+	total, champions := 0, 0
+	copies := make(map[string]int)
+	var unique []DeckEntry
+	for _, de := range d {
+		count := int(de.Count)
+		total += count
+		if card.IsChampion(de.Card) {
+			champions += count
+		}
+		if _, ok := copies[de.Card.CardCode]; !ok {
+			unique = append(unique, de)
+		}
+		copies[de.Card.CardCode] += count
+	}
+
+	if total != Size {
+		l.Violations = append(l.Violations, Violation{Rule: SizeRule, Count: total, Limit: Size})
+	}
+	for _, de := range unique {
+		if n := copies[de.Card.CardCode]; n > MaxCopies {
+			l.Violations = append(l.Violations, Violation{Rule: CopiesRule, Count: n, Limit: MaxCopies, Card: de.Card.Name})
+		}
+	}
+	if champions > MaxChampions {
+		l.Violations = append(l.Violations, Violation{Rule: ChampionsRule, Count: champions, Limit: MaxChampions})
+	}
+	if used := deckRegions(d); len(used) > MaxRegions {
+		l.Violations = append(l.Violations, Violation{Rule: RegionsRule, Count: len(used), Limit: MaxRegions, Regions: used})
+	}
+
+	for _, f := range Formats {
+		for _, de := range unique {
+			if !slices.Contains(de.Card.FormatRefs, f.Ref()) {
+				l.Illegal[f] = append(l.Illegal[f], de.Card.Name)
+			}
+		}
+	}
+	return l
+}
+
+// deckRegions returns the fewest regions the cards of a deck can be from, by
+// name.
+func deckRegions(d Deck) []string {
+	var options [][]string
+	required := make(map[string]bool)
+	for _, de := range d {
+		o := cardRegions(de)
+		switch len(o) {
+		case 0:
+		case 1:
+			required[o[0]] = true
+		default:
+			options = append(options, o)
+		}
+	}
+
+	// Cards of a single region must use it, so only the cards from several
+	// regions none of which is required are left to choose for.
+	var left [][]string
+	candidates := make(map[string]bool)
+	for _, o := range options {
+		if !slices.ContainsFunc(o, func(r string) bool { return required[r] }) {
+			left = append(left, o)
+			for _, r := range o {
+				candidates[r] = true
+			}
+		}
+	}
+	sorted := make([]string, 0, len(candidates))
+	for r := range candidates {
+		sorted = append(sorted, r)
+	}
+	sort.Strings(sorted)
+
+	var chosen []string
+	for k := 0; k <= len(sorted); k++ {
+		if chosen = coverRegions(left, sorted, nil, k); chosen != nil {
+			break
+		}
+	}
+
+	used := chosen
+	for r := range required {
+		used = append(used, r)
+	}
+	sort.Strings(used)
+	return used
+}
+
+// coverRegions returns k regions of candidates, added to chosen, which every
+// card of left is from, or nil if there are none.
+func coverRegions(left [][]string, candidates []string, chosen []string, k int) []string {
+	if k == 0 {
+		for _, o := range left {
+			if !slices.ContainsFunc(o, func(r string) bool { return slices.Contains(chosen, r) }) {
+				return nil
+			}
+		}
+		return append([]string{}, chosen...)
+	}
+	for i, r := range candidates {
+		if found := coverRegions(left, candidates[i+1:], append(chosen, r), k-1); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// cardRegions returns the regions a card can count as, its name for
+// Runeterra champions.
+func cardRegions(de DeckEntry) []string {
+	var rs []string
+	for _, r := range de.Card.RegionRefs {
+		if r == runeterraRef && card.IsChampion(de.Card) {
+			r = de.Card.Name
+		}
+		rs = append(rs, r)
+	}
+	return rs
+}
@@ -0,0 +1,91 @@
+package deck_test
+
+import (
+	"github.com/dneto/sai-scout/internal/deck"
+	"github.com/dneto/sai-scout/internal/deck/decktest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Validate", func() {
+	It("accepts legal decks", func() {
+		l := deck.Validate(decktest.Legal())
+		Expect(l.Violations).To(BeEmpty())
+		Expect(l.Legal(deck.Standard)).To(BeTrue())
+		Expect(l.Legal(deck.Eternal)).To(BeTrue())
+	})
+
+	It("checks the size of the deck", func() {
+		d := decktest.Legal()
+		d[2].Count = 1
+		Expect(deck.Validate(d).Violations).To(Equal([]deck.Violation{{Rule: deck.SizeRule, Count: 39, Limit: 40}}))
+	})
+
+	It("checks the copies of each card, adding up the entries of the same card", func() {
+		d := decktest.Legal()
+		d[2].Count = 1
+		d = append(d, deck.DeckEntry{Count: 3, Card: d[2].Card})
+		d[3].Count = 0
+		l := deck.Validate(d)
+		Expect(l.Violations).To(ContainElement(deck.Violation{Rule: deck.CopiesRule, Count: 4, Limit: 3, Card: "01XX000"}))
+		Expect(l.Legal(deck.Standard)).To(BeFalse())
+	})
+
+	It("checks the number of champions", func() {
+		d := decktest.Legal()
+		d[2].Card = decktest.Champion("01NX001", "Noxus")
+		Expect(deck.Validate(d).Violations).To(Equal([]deck.Violation{{Rule: deck.ChampionsRule, Count: 8, Limit: 6}}))
+	})
+
+	Context("regions", func() {
+		It("rejects decks from 3 regions", func() {
+			d := decktest.Legal()
+			d[2].Card = decktest.Follower("01IO001", "Ionia")
+			Expect(deck.Validate(d).Violations).To(Equal([]deck.Violation{
+				{Rule: deck.RegionsRule, Count: 3, Limit: 2, Regions: []string{"Demacia", "Ionia", "Noxus"}},
+			}))
+		})
+
+		It("counts cards from several regions as being from any of them", func() {
+			d := decktest.Legal()
+			d[2].Card = decktest.Follower("06BW001", "Bilgewater", "Noxus")
+			Expect(deck.Validate(d).Violations).To(BeEmpty())
+		})
+
+		It("chooses the regions of cards from several regions", func() {
+			d := decktest.Legal()[:5]
+			d[0].Card = decktest.Follower("06BW001", "Bilgewater", "Noxus")
+			d[1].Card = decktest.Follower("06SI001", "ShadowIsles", "Noxus")
+			d[2].Card = decktest.Follower("06SI002", "ShadowIsles", "Ionia")
+			d[3].Card = decktest.Follower("06SI003", "ShadowIsles", "Noxus")
+			d[4].Card = decktest.Follower("06BW002", "Bilgewater", "Ionia")
+			Expect(deck.Validate(d).Violations).To(Equal([]deck.Violation{{Rule: deck.SizeRule, Count: 12, Limit: 40}}))
+
+			d[4].Card = decktest.Follower("06DE001", "Demacia", "Freljord")
+			Expect(deck.Validate(d).Violations).To(ContainElement(
+				deck.Violation{Rule: deck.RegionsRule, Count: 3, Limit: 2, Regions: []string{"Bilgewater", "Demacia", "ShadowIsles"}},
+			))
+		})
+
+		It("counts each Runeterra champion as a region", func() {
+			d := decktest.Legal()
+			d[0].Card = decktest.Champion("06RU002", "Runeterra")
+			d[0].Card.Name = "Jhin"
+			Expect(deck.Validate(d).Violations).To(Equal([]deck.Violation{
+				{Rule: deck.RegionsRule, Count: 3, Limit: 2, Regions: []string{"Demacia", "Jhin", "Noxus"}},
+			}))
+		})
+	})
+
+	It("lists the cards not legal in each format", func() {
+		d := decktest.Legal()
+		d[2].Card.FormatRefs = []string{deck.Eternal.Ref()}
+		d[3].Card.FormatRefs = nil
+		l := deck.Validate(d)
+		Expect(l.Violations).To(BeEmpty())
+		Expect(l.Illegal[deck.Standard]).To(Equal([]string{"01XX000", "01XX001"}))
+		Expect(l.Illegal[deck.Eternal]).To(Equal([]string{"01XX001"}))
+		Expect(l.Legal(deck.Standard)).To(BeFalse())
+		Expect(l.Legal(deck.Eternal)).To(BeFalse())
+	})
+})
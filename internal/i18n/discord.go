@@ -0,0 +1,31 @@
+package i18n
+
+import "github.com/bwmarrin/discordgo"
+
+// discordLocales maps the Discord locales to the closest locale of the game.
+// Discord locales the game is not translated to are left out.
+var discordLocales = map[discordgo.Locale]Locale{
+	discordgo.German:       "de_de",
+	discordgo.EnglishUS:    "en_us",
+	discordgo.EnglishGB:    "en_us",
+	discordgo.SpanishES:    "es_es",
+	"es-419":               "es_mx",
+	discordgo.French:       "fr_fr",
+	discordgo.Italian:      "it_it",
+	discordgo.Japanese:     "ja_jp",
+	discordgo.Korean:       "ko_kr",
+	discordgo.Polish:       "pl_pl",
+	discordgo.PortugueseBR: "pt_br",
+	discordgo.Russian:      "ru_ru",
+	discordgo.Thai:         "th_th",
+	discordgo.Turkish:      "tr_tr",
+	discordgo.Vietnamese:   "vi_vn",
+	discordgo.ChineseTW:    "zh_tw",
+}
+
+// FromDiscord returns the locale of the game closest to a Discord locale, if
+// the game is translated to it.
+func FromDiscord(l discordgo.Locale) (Locale, bool) {
+	locale, ok := discordLocales[l]
+	return locale, ok
+}
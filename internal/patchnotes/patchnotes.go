@@ -0,0 +1,126 @@
+package patchnotes
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dneto/sai-scout/internal/repository"
+)
+
+// Field is a card attribute tracked between versions. Its value is also the
+// message ID used to localize it.
+type Field string
+
+const (
+	Cost        Field = "Cost"
+	Attack      Field = "Attack"
+	Health      Field = "Health"
+	Keywords    Field = "Keywords"
+	Description Field = "Description"
+	LevelUp     Field = "Level Up"
+	Formats     Field = "Formats"
+	Collectible Field = "Collectible"
+)
+
+// Change is a single field that differs between two versions of a card.
+type Change struct {
+	Field Field
+	From  string
+	To    string
+}
+
+// CardChange holds the changes of a card, which is the card as it is in the
+// newer version.
+type CardChange struct {
+	Card    *repository.Card
+	Changes []Change
+}
+
+// Notes are the differences between two versions of the cards, sorted by
+// card code.
+type Notes struct {
+	Added   []*repository.Card
+	Removed []*repository.Card
+	Changed []CardChange
+}
+
+// Empty returns true if no card was added, removed or changed.
+func (n Notes) Empty() bool {
+	return len(n.Added) == 0 && len(n.Removed) == 0 && len(n.Changed) == 0
+}
+
+// Diff compares the cards of two versions.
+func Diff(from []*repository.Card, to []*repository.Card) Notes {
+	old := make(map[string]*repository.Card, len(from))
+	for _, c := range from {
+		old[c.CardCode] = c
+	}
+
+	notes := Notes{}
+	current := make(map[string]bool, len(to))
+	for _, c := range to {
+		current[c.CardCode] = true
+
+		o, found := old[c.CardCode]
+		if !found {
+			notes.Added = append(notes.Added, c)
+			continue
+		}
+
+		if changes := compare(o, c); len(changes) > 0 {
+			notes.Changed = append(notes.Changed, CardChange{Card: c, Changes: changes})
+		}
+	}
+
+	for _, c := range from {
+		if !current[c.CardCode] {
+			notes.Removed = append(notes.Removed, c)
+		}
+	}
+
+	slices.SortFunc(notes.Added, byCardCode)
+	slices.SortFunc(notes.Removed, byCardCode)
+	slices.SortFunc(notes.Changed, func(a, b CardChange) int {
+		return byCardCode(a.Card, b.Card)
+	})
+
+	return notes
+}
+
+func compare(from *repository.Card, to *repository.Card) []Change {
+	var changes []Change
+	add := func(field Field, changed bool, from string, to string) {
+		if changed {
+			changes = append(changes, Change{Field: field, From: from, To: to})
+		}
+	}
+
+	add(Cost, from.Cost != to.Cost, strconv.Itoa(from.Cost), strconv.Itoa(to.Cost))
+	add(Attack, from.Attack != to.Attack, strconv.Itoa(from.Attack), strconv.Itoa(to.Attack))
+	add(Health, from.Health != to.Health, strconv.Itoa(from.Health), strconv.Itoa(to.Health))
+	add(Keywords, !sameRefs(from.KeywordRefs, to.KeywordRefs), strings.Join(from.Keywords, ", "), strings.Join(to.Keywords, ", "))
+	add(Description, from.DescriptionRaw != to.DescriptionRaw, from.DescriptionRaw, to.DescriptionRaw)
+	add(LevelUp, from.LevelupDescriptionRaw != to.LevelupDescriptionRaw, from.LevelupDescriptionRaw, to.LevelupDescriptionRaw)
+	add(Formats, !sameRefs(from.FormatRefs, to.FormatRefs), strings.Join(from.Formats, ", "), strings.Join(to.Formats, ", "))
+	add(Collectible, from.Collectible != to.Collectible, strconv.FormatBool(from.Collectible), strconv.FormatBool(to.Collectible))
+
+	return changes
+}
+
+// sameRefs compares refs ignoring their order.
+func sameRefs(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
+
+func byCardCode(a *repository.Card, b *repository.Card) int {
+	return cmp.Compare(a.CardCode, b.CardCode)
+}
@@ -0,0 +1,97 @@
+package patchnotes_test
+
+import (
+	"testing"
+
+	"github.com/dneto/sai-scout/internal/patchnotes"
+	"github.com/dneto/sai-scout/internal/repository"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPatchNotes(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Patch Notes Suite")
+}
+
+var _ = Describe("Diff", func() {
+	It("returns empty notes for the same cards", func() {
+		notes := patchnotes.Diff([]*repository.Card{annie()}, []*repository.Card{annie()})
+		Expect(notes.Empty()).To(BeTrue())
+	})
+
+	It("reports added and removed cards", func() {
+		removed := &repository.Card{CardCode: "01DE001", Name: "Vanguard Defender"}
+		added := &repository.Card{CardCode: "01NX002", Name: "Legion Rearguard"}
+
+		notes := patchnotes.Diff(
+			[]*repository.Card{annie(), removed},
+			[]*repository.Card{added, annie()},
+		)
+
+		Expect(notes.Added).To(Equal([]*repository.Card{added}))
+		Expect(notes.Removed).To(Equal([]*repository.Card{removed}))
+		Expect(notes.Changed).To(BeEmpty())
+	})
+
+	It("reports changed fields", func() {
+		buffed := annie()
+		buffed.Cost = 1
+		buffed.Health = 3
+		buffed.Keywords = []string{"Quick Attack"}
+		buffed.KeywordRefs = []string{"QuickStrike"}
+		buffed.DescriptionRaw = "Play: Deal 1 to anything."
+		buffed.Collectible = false
+
+		notes := patchnotes.Diff([]*repository.Card{annie()}, []*repository.Card{buffed})
+
+		Expect(notes.Added).To(BeEmpty())
+		Expect(notes.Removed).To(BeEmpty())
+		Expect(notes.Changed).To(Equal([]patchnotes.CardChange{{
+			Card: buffed,
+			Changes: []patchnotes.Change{
+				{Field: patchnotes.Cost, From: "2", To: "1"},
+				{Field: patchnotes.Health, From: "2", To: "3"},
+				{Field: patchnotes.Keywords, From: "", To: "Quick Attack"},
+				{Field: patchnotes.Description, From: "", To: "Play: Deal 1 to anything."},
+				{Field: patchnotes.Collectible, From: "true", To: "false"},
+			},
+		}}))
+	})
+
+	It("ignores the order of keywords and formats", func() {
+		from := annie()
+		from.KeywordRefs = []string{"Fearsome", "Elusive"}
+		from.FormatRefs = []string{"client_Formats_Standard_name", "client_Formats_Eternal_name"}
+		to := annie()
+		to.KeywordRefs = []string{"Elusive", "Fearsome"}
+		to.FormatRefs = []string{"client_Formats_Eternal_name", "client_Formats_Standard_name"}
+
+		notes := patchnotes.Diff([]*repository.Card{from}, []*repository.Card{to})
+		Expect(notes.Empty()).To(BeTrue())
+	})
+
+	It("sorts cards by code", func() {
+		notes := patchnotes.Diff(nil, []*repository.Card{
+			{CardCode: "02NX003"},
+			{CardCode: "01DE001"},
+		})
+
+		Expect(notes.Added[0].CardCode).To(Equal("01DE001"))
+		Expect(notes.Added[1].CardCode).To(Equal("02NX003"))
+	})
+})
+
+func annie() *repository.Card {
+	return &repository.Card{
+		CardCode:              "06NX005",
+		Name:                  "Annie",
+		Cost:                  2,
+		Attack:                1,
+		Health:                2,
+		LevelupDescriptionRaw: "I've seen 7+ allied or enemy units die this game.",
+		Collectible:           true,
+		RarityRef:             "Champion",
+		TypeRef:               "Unit",
+	}
+}
@@ -0,0 +1,98 @@
+// Package prefs resolves the settings used to answer an interaction from the
+// options of the command and the preferences of the user, the channel and the
+// guild.
+package prefs
+
+import (
+	"context"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dneto/sai-scout/internal/i18n"
+	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/dneto/sai-scout/pkg/discord"
+	"github.com/rs/zerolog/log"
+)
+
+// Default are the settings used when nobody chose them.
+var Default = repository.Preferences{
+	Language:    string(i18n.Default),
+	URLTemplate: "https://runeterra.ar/lor/decks/code/{{code}}",
+	URLLabel:    "Runeterra AR",
+}
+
+// Source is the repository the preferences are read from.
+type Source interface {
+	repository.GuildConfigRepository
+	repository.UserPrefsRepository
+}
+
+// Resolver finds the settings of each interaction.
+type Resolver struct {
+	src Source
+}
+
+func NewResolver(src Source) *Resolver {
+	return &Resolver{src: src}
+}
+
+// Resolve returns the settings of an interaction. Each setting is taken from
+// the first level that has it: explicit, which are the options of the
+// command, then the preferences of the user, of the channel and of the guild,
+// then the Discord locales of the user and of the guild, in the order the
+// guild chose, then Default. Preferences that can't be read are skipped.
+func (r *Resolver) Resolve(ctx context.Context, in *discordgo.InteractionCreate, explicit repository.Preferences) repository.Preferences {
+	levels := []repository.Preferences{explicit}
+
+	if user := discord.UserID(in); user != "" {
+		prefs, err := r.src.UserPrefs(ctx, user)
+		if err != nil {
+			log.Error().Err(err).Str("user", user).Msg("failed to load user preferences")
+		} else {
+			levels = append(levels, *prefs)
+		}
+	}
+
+	guildLocaleFirst := false
+	if in.GuildID != "" {
+		config, err := r.src.GuildConfig(ctx, in.GuildID)
+		if err != nil {
+			log.Error().Err(err).Str("guild", in.GuildID).Msg("failed to load guild config")
+		} else {
+			levels = append(levels, config.Channels[in.ChannelID], config.Preferences)
+			guildLocaleFirst = config.GuildLocaleFirst
+		}
+	}
+
+	locales := []repository.Preferences{discordLocale(&in.Locale), discordLocale(in.GuildLocale)}
+	if guildLocaleFirst {
+		locales[0], locales[1] = locales[1], locales[0]
+	}
+	levels = append(levels, locales...)
+
+	return Merge(append(levels, Default)...)
+}
+
+// Merge returns the first setting of the levels that is not empty. A website
+// template is taken together with its label.
+func Merge(levels ...repository.Preferences) repository.Preferences {
+	var merged repository.Preferences
+	for _, l := range levels {
+		if merged.Language == "" {
+			merged.Language = l.Language
+		}
+		if merged.URLTemplate == "" {
+			merged.URLTemplate, merged.URLLabel = l.URLTemplate, l.URLLabel
+		}
+	}
+	return merged
+}
+
+// discordLocale returns the language of a Discord locale, which is empty
+// when the game is not translated to it.
+func discordLocale(l *discordgo.Locale) repository.Preferences {
+	if l == nil {
+		return repository.Preferences{}
+	}
+	locale, _ := i18n.FromDiscord(*l)
+	return repository.Preferences{Language: string(locale)}
+}
@@ -0,0 +1,128 @@
+package prefs_test
+
+import (
+	"context"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dneto/sai-scout/internal/prefs"
+	"github.com/dneto/sai-scout/internal/repository"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Resolver", func() {
+	var (
+		ctx      = context.Background()
+		repo     *repository.Memory
+		resolver *prefs.Resolver
+		in       *discordgo.InteractionCreate
+	)
+
+	BeforeEach(func() {
+		repo = repository.NewMemory()
+		resolver = prefs.NewResolver(repo)
+		in = &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			GuildID:   "guild",
+			ChannelID: "channel",
+			Member:    &discordgo.Member{User: &discordgo.User{ID: "user"}},
+		}}
+	})
+
+	setGuild := func(language string, channelLanguage string) {
+		GinkgoHelper()
+		Expect(repo.UpdateGuildConfig(ctx, "guild", func(c *repository.GuildConfig) {
+			c.Language = language
+			c.URLTemplate, c.URLLabel = "https://guild.example/{{code}}", "Guild"
+			c.Channels = map[string]repository.Preferences{"channel": {Language: channelLanguage}}
+		})).To(Succeed())
+	}
+
+	setUser := func(language string) {
+		GinkgoHelper()
+		Expect(repo.UpdateUserPrefs(ctx, "user", func(p *repository.Preferences) {
+			p.Language = language
+		})).To(Succeed())
+	}
+
+	It("uses the defaults when nothing was chosen", func() {
+		Expect(resolver.Resolve(ctx, in, repository.Preferences{})).To(Equal(prefs.Default))
+	})
+
+	It("prefers the guild to the defaults", func() {
+		setGuild("es_es", "")
+
+		p := resolver.Resolve(ctx, in, repository.Preferences{})
+		Expect(p.Language).To(Equal("es_es"))
+		Expect(p.URLTemplate).To(Equal("https://guild.example/{{code}}"))
+		Expect(p.URLLabel).To(Equal("Guild"))
+	})
+
+	It("prefers the channel to the guild", func() {
+		setGuild("es_es", "ja_jp")
+
+		Expect(resolver.Resolve(ctx, in, repository.Preferences{}).Language).To(Equal("ja_jp"))
+
+		in.ChannelID = "other"
+		Expect(resolver.Resolve(ctx, in, repository.Preferences{}).Language).To(Equal("es_es"))
+	})
+
+	It("prefers the user to the channel", func() {
+		setGuild("es_es", "ja_jp")
+		setUser("pt_br")
+
+		Expect(resolver.Resolve(ctx, in, repository.Preferences{}).Language).To(Equal("pt_br"))
+	})
+
+	It("prefers explicit options to the user", func() {
+		setUser("pt_br")
+
+		Expect(resolver.Resolve(ctx, in, repository.Preferences{Language: "de_de"}).Language).To(Equal("de_de"))
+	})
+
+	Context("Discord locales", func() {
+		BeforeEach(func() {
+			guildLocale := discordgo.PortugueseBR
+			in.Locale, in.GuildLocale = discordgo.Japanese, &guildLocale
+		})
+
+		It("uses the locale of the user when nothing was chosen", func() {
+			Expect(resolver.Resolve(ctx, in, repository.Preferences{}).Language).To(Equal("ja_jp"))
+		})
+
+		It("uses the locale of the guild when the guild prefers it", func() {
+			Expect(repo.UpdateGuildConfig(ctx, "guild", func(c *repository.GuildConfig) {
+				c.GuildLocaleFirst = true
+			})).To(Succeed())
+
+			Expect(resolver.Resolve(ctx, in, repository.Preferences{}).Language).To(Equal("pt_br"))
+		})
+
+		It("skips locales the game is not translated to", func() {
+			in.Locale = discordgo.Ukrainian
+			Expect(resolver.Resolve(ctx, in, repository.Preferences{}).Language).To(Equal("pt_br"))
+		})
+
+		It("prefers the guild configuration to the locales", func() {
+			setGuild("es_es", "")
+			Expect(resolver.Resolve(ctx, in, repository.Preferences{}).Language).To(Equal("es_es"))
+		})
+	})
+
+	It("uses the user preferences in direct messages", func() {
+		setUser("pt_br")
+		in.GuildID, in.Member, in.User = "", nil, &discordgo.User{ID: "user"}
+
+		Expect(resolver.Resolve(ctx, in, repository.Preferences{}).Language).To(Equal("pt_br"))
+	})
+})
+
+var _ = Describe("Merge", func() {
+	It("takes a website template together with its label", func() {
+		merged := prefs.Merge(
+			repository.Preferences{URLLabel: "Label without template"},
+			repository.Preferences{URLTemplate: "https://example.com/{{code}}", URLLabel: "Example"},
+		)
+		Expect(merged.URLTemplate).To(Equal("https://example.com/{{code}}"))
+		Expect(merged.URLLabel).To(Equal("Example"))
+	})
+})
@@ -0,0 +1,13 @@
+package prefs_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPrefs(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Prefs Suite")
+}
@@ -0,0 +1,266 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SyntaxError is a query that can't be parsed, with the position of the
+// character where it went wrong, starting at 1.
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Pos, e.Msg)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOp
+	tokenMinus
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// ops are the operators between a field and its value, longest first.
+var ops = []string{"!=", "<=", ">=", ":", "=", "<", ">"}
+
+// lex splits a query into tokens. Words end at spaces, parentheses, quotes
+// and operators; quoted strings keep them, with \" and \\ as escapes.
+func lex(s string) ([]token, error) {
+	runes := []rune(s)
+	var tokens []token
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, value: "(", pos: i + 1})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, value: ")", pos: i + 1})
+			i++
+		case r == '-' && (len(tokens) == 0 || tokens[len(tokens)-1].kind != tokenOp):
+			// A minus negates the term it starts, unless it starts a value.
+			tokens = append(tokens, token{kind: tokenMinus, value: "-", pos: i + 1})
+			i++
+		case r == '"':
+			var b strings.Builder
+			start := i
+			for i++; ; i++ {
+				if i == len(runes) {
+					return nil, &SyntaxError{Pos: start + 1, Msg: "unterminated quoted text"}
+				}
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				} else if runes[i] == '"' {
+					break
+				}
+				b.WriteRune(runes[i])
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenString, value: b.String(), pos: start + 1})
+		default:
+			if op := opAt(runes, i); op != "" {
+				tokens = append(tokens, token{kind: tokenOp, value: op, pos: i + 1})
+				i += len(op)
+				continue
+			}
+			start := i
+			for i < len(runes) && !endsWord(runes, i) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenWord, value: string(runes[start:i]), pos: start + 1})
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes) + 1}), nil
+}
+
+func opAt(runes []rune, i int) string {
+	for _, op := range ops {
+		if strings.HasPrefix(string(runes[i:min(i+2, len(runes))]), op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func endsWord(runes []rune, i int) bool {
+	r := runes[i]
+	return unicode.IsSpace(r) || r == '(' || r == ')' || r == '"' || opAt(runes, i) != ""
+}
+
+// isKeyword tells whether t is an unquoted keyword, in any case.
+func isKeyword(t token, keyword string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.value, keyword)
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// Parse parses a query. Terms are either full-text, matching the name and the
+// text of cards, or a field, an operator and a value, like cost<=2 or
+// region:Noxus. Terms next to each other must all match, unless joined by OR.
+// NOT or a minus negates a term and parentheses group them.
+func Parse(s string) (Expr, error) {
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, &SyntaxError{Pos: 1, Msg: "empty query"}
+	}
+
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("unexpected %q", t.value)}
+	}
+	return expr, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) or() (Expr, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for isKeyword(p.peek(), "OR") {
+		p.next()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = or{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (Expr, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind == tokenEOF || t.kind == tokenRParen || isKeyword(t, "OR") {
+			return left, nil
+		}
+		if isKeyword(t, "AND") {
+			p.next()
+		}
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = and{left, right}
+	}
+}
+
+func (p *parser) unary() (Expr, error) {
+	if t := p.peek(); t.kind == tokenMinus || isKeyword(t, "NOT") {
+		p.next()
+		expr, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return not{expr}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (Expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenLParen:
+		expr, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, &SyntaxError{Pos: closing.pos, Msg: "missing )"}
+		}
+		return expr, nil
+	case tokenString:
+		return text{value: t.value}, nil
+	case tokenWord:
+		if isKeyword(t, "AND") || isKeyword(t, "OR") {
+			return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("missing term before %s", strings.ToUpper(t.value))}
+		}
+		if p.peek().kind == tokenOp {
+			return p.field(t)
+		}
+		return text{value: t.value}, nil
+	case tokenEOF:
+		return nil, &SyntaxError{Pos: t.pos, Msg: "missing term at the end"}
+	default:
+		return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("unexpected %q", t.value)}
+	}
+}
+
+// field parses the operator and the value of a field term.
+func (p *parser) field(name token) (Expr, error) {
+	f, ok := fields[strings.ToLower(name.value)]
+	if !ok {
+		return nil, &SyntaxError{Pos: name.pos, Msg: fmt.Sprintf("unknown field %q", name.value)}
+	}
+
+	op := p.next()
+	value := p.next()
+	if value.kind != tokenWord && value.kind != tokenString {
+		return nil, &SyntaxError{Pos: value.pos, Msg: fmt.Sprintf("missing value of %s", f.name)}
+	}
+
+	term := fieldTerm{field: f, op: op.value, value: value.value}
+	switch f.kind {
+	case numberField:
+		n, err := strconv.Atoi(value.value)
+		if err != nil {
+			return nil, &SyntaxError{Pos: value.pos, Msg: fmt.Sprintf("%s must be a number", f.name)}
+		}
+		term.number = n
+	case boolField:
+		b, ok := bools[strings.ToLower(value.value)]
+		if !ok {
+			return nil, &SyntaxError{Pos: value.pos, Msg: fmt.Sprintf("%s must be yes or no", f.name)}
+		}
+		term.number = b
+	}
+	if f.kind != numberField && op.value != ":" && op.value != "=" && op.value != "!=" {
+		return nil, &SyntaxError{Pos: op.pos, Msg: fmt.Sprintf("%s can't be compared with %s", f.name, op.value)}
+	}
+	return term, nil
+}
+
+var bools = map[string]int{"yes": 1, "true": 1, "no": 0, "false": 0}
@@ -0,0 +1,192 @@
+// Package query parses and evaluates card queries, like
+// `cost<=2 region:Noxus type:Spell keyword:Burst text:"deal 1"`.
+//
+// Queries are evaluated on the cards themselves instead of being translated
+// to the query language of each storage, so they match the same cards
+// whatever the storage is.
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/dneto/sai-scout/internal/search"
+)
+
+// Expr is a parsed query.
+type Expr interface {
+	// Match tells whether a card matches the query.
+	Match(c *repository.Card) bool
+	// String returns the query with its terms grouped by parentheses.
+	String() string
+}
+
+type and struct{ left, right Expr }
+
+func (e and) Match(c *repository.Card) bool { return e.left.Match(c) && e.right.Match(c) }
+func (e and) String() string                { return fmt.Sprintf("(%s AND %s)", e.left, e.right) }
+
+type or struct{ left, right Expr }
+
+func (e or) Match(c *repository.Card) bool { return e.left.Match(c) || e.right.Match(c) }
+func (e or) String() string                { return fmt.Sprintf("(%s OR %s)", e.left, e.right) }
+
+type not struct{ expr Expr }
+
+func (e not) Match(c *repository.Card) bool { return !e.expr.Match(c) }
+func (e not) String() string                { return fmt.Sprintf("NOT %s", e.expr) }
+
+// text is a full-text term, matching cards with it in their name or text.
+type text struct{ value string }
+
+func (e text) Match(c *repository.Card) bool {
+	v := fold(e.value)
+	return strings.Contains(fold(c.Name), v) ||
+		strings.Contains(fold(c.DescriptionRaw), v) ||
+		strings.Contains(fold(c.LevelupDescriptionRaw), v)
+}
+
+func (e text) String() string { return fmt.Sprintf("%q", e.value) }
+
+type fieldKind int
+
+const (
+	// numberField values are compared as numbers.
+	numberField fieldKind = iota
+	// textField values contain the value with ":" and are equal to it with
+	// "=".
+	textField
+	// enumField values are names, like regions or keywords, either localized
+	// or refs. Their spaces and punctuation are ignored, so "Piltover & Zaun"
+	// and "PiltoverZaun" are the same.
+	enumField
+	// boolField values are yes or no.
+	boolField
+)
+
+type field struct {
+	name   string
+	kind   fieldKind
+	number func(c *repository.Card) int
+	values func(c *repository.Card) []string
+}
+
+// fields are the fields of cards queries can use, by name and by alias.
+var fields = map[string]*field{}
+
+func init() {
+	add := func(f *field, aliases ...string) {
+		fields[f.name] = f
+		for _, a := range aliases {
+			fields[a] = f
+		}
+	}
+	strs := func(values ...string) []string { return values }
+
+	add(&field{name: "cost", kind: numberField, number: func(c *repository.Card) int { return c.Cost }}, "mana")
+	add(&field{name: "attack", kind: numberField, number: func(c *repository.Card) int { return c.Attack }}, "power", "atk")
+	add(&field{name: "health", kind: numberField, number: func(c *repository.Card) int { return c.Health }}, "hp")
+	add(&field{name: "collectible", kind: boolField, number: func(c *repository.Card) int {
+		if c.Collectible {
+			return 1
+		}
+		return 0
+	}})
+
+	add(&field{name: "name", kind: textField, values: func(c *repository.Card) []string { return strs(c.Name) }})
+	add(&field{name: "text", kind: textField, values: func(c *repository.Card) []string {
+		return strs(c.DescriptionRaw, c.LevelupDescriptionRaw)
+	}}, "description")
+	add(&field{name: "flavor", kind: textField, values: func(c *repository.Card) []string { return strs(c.FlavorText) }})
+	add(&field{name: "artist", kind: textField, values: func(c *repository.Card) []string { return strs(c.ArtistName) }})
+
+	add(&field{name: "region", kind: enumField, values: func(c *repository.Card) []string {
+		return append(append([]string(nil), c.Regions...), c.RegionRefs...)
+	}}, "regions")
+	add(&field{name: "keyword", kind: enumField, values: func(c *repository.Card) []string {
+		return append(append([]string(nil), c.Keywords...), c.KeywordRefs...)
+	}}, "keywords", "kw")
+	add(&field{name: "format", kind: enumField, values: func(c *repository.Card) []string {
+		return append(append([]string(nil), c.Formats...), c.FormatRefs...)
+	}}, "formats")
+	add(&field{name: "subtype", kind: enumField, values: func(c *repository.Card) []string { return c.Subtypes }}, "subtypes")
+	add(&field{name: "type", kind: enumField, values: func(c *repository.Card) []string { return strs(c.Type, c.TypeRef) }})
+	add(&field{name: "supertype", kind: enumField, values: func(c *repository.Card) []string {
+		return strs(c.Supertype, c.SupertypeRef)
+	}})
+	add(&field{name: "rarity", kind: enumField, values: func(c *repository.Card) []string { return strs(c.Rarity, c.RarityRef) }})
+	add(&field{name: "speed", kind: enumField, values: func(c *repository.Card) []string {
+		return strs(c.SpellSpeed, c.SpellSpeedRef)
+	}}, "spellspeed")
+	add(&field{name: "set", kind: enumField, values: func(c *repository.Card) []string { return strs(c.Set) }})
+	add(&field{name: "code", kind: enumField, values: func(c *repository.Card) []string { return strs(c.CardCode) }})
+}
+
+// fieldTerm matches cards by a field, like cost<=2 or region:Noxus.
+type fieldTerm struct {
+	field *field
+	op    string
+	value string
+	// number is the value of number and bool fields.
+	number int
+}
+
+func (e fieldTerm) Match(c *repository.Card) bool {
+	if e.op == "!=" {
+		return !fieldTerm{field: e.field, op: "=", value: e.value, number: e.number}.Match(c)
+	}
+
+	switch e.field.kind {
+	case numberField, boolField:
+		n := e.field.number(c)
+		switch e.op {
+		case "<":
+			return n < e.number
+		case "<=":
+			return n <= e.number
+		case ">":
+			return n > e.number
+		case ">=":
+			return n >= e.number
+		default:
+			return n == e.number
+		}
+	case textField:
+		v := fold(e.value)
+		for _, s := range e.field.values(c) {
+			if e.op == ":" && strings.Contains(fold(s), v) || fold(s) == v {
+				return true
+			}
+		}
+	case enumField:
+		v := enumKey(e.value)
+		for _, s := range e.field.values(c) {
+			if s != "" && enumKey(s) == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (e fieldTerm) String() string {
+	return fmt.Sprintf("%s%s%q", e.field.name, e.op, e.value)
+}
+
+// fold normalizes text to match it regardless of case and accents.
+func fold(s string) string {
+	return strings.Join(strings.Fields(search.Fold(s)), " ")
+}
+
+// enumKey normalizes a name to match it regardless of case, accents, spaces
+// and punctuation.
+func enumKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, search.Fold(s))
+}
@@ -0,0 +1,111 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/dneto/sai-scout/internal/query"
+	"github.com/dneto/sai-scout/internal/repository"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestQuery(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Query Suite")
+}
+
+var (
+	decimate = &repository.Card{
+		CardCode: "01NX004", Name: "Decimate", Cost: 5, Collectible: true,
+		Type: "Spell", TypeRef: "Spell", SpellSpeed: "Slow", SpellSpeedRef: "Slow",
+		Regions: []string{"Noxus"}, RegionRefs: []string{"Noxus"},
+		Keywords: []string{"Slow"}, KeywordRefs: []string{"Slow"},
+		DescriptionRaw: "Deal 4 to the enemy Nexus.",
+	}
+	mysticShot = &repository.Card{
+		CardCode: "01PZ052", Name: "Mystic Shot", Cost: 2, Collectible: true,
+		Type: "Conjuro", TypeRef: "Spell", SpellSpeed: "Ráfaga", SpellSpeedRef: "Burst",
+		Regions: []string{"Piltover y Zaun"}, RegionRefs: []string{"PiltoverZaun"},
+		Keywords: []string{"Ráfaga"}, KeywordRefs: []string{"Burst"},
+		DescriptionRaw: "Deal 2 to anything.", Formats: []string{"Eterno"}, FormatRefs: []string{"client_Formats_Eternal_name"},
+	}
+	legion = &repository.Card{
+		CardCode: "01NX002", Name: "Legion Rearguard", Cost: 1, Attack: 3, Health: 2,
+		Type: "Unit", TypeRef: "Unit", Regions: []string{"Noxus"}, RegionRefs: []string{"Noxus"},
+		FlavorText: "Front line", ArtistName: "SIXMOREVODKA",
+	}
+	cards = []*repository.Card{decimate, mysticShot, legion}
+)
+
+func matching(q string) []string {
+	GinkgoHelper()
+	expr, err := query.Parse(q)
+	Expect(err).ToNot(HaveOccurred())
+
+	var codes []string
+	for _, c := range cards {
+		if expr.Match(c) {
+			codes = append(codes, c.CardCode)
+		}
+	}
+	return codes
+}
+
+var _ = Describe("Parse", func() {
+	DescribeTable("groups terms",
+		func(q string, parsed string) {
+			expr, err := query.Parse(q)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(expr.String()).To(Equal(parsed))
+		},
+		Entry("implicit AND", `cost<=2 region:Noxus`, `(cost<="2" AND region:"Noxus")`),
+		Entry("AND before OR", `a OR b c`, `("a" OR ("b" AND "c"))`),
+		Entry("parentheses", `(a or b) and c`, `(("a" OR "b") AND "c")`),
+		Entry("negation", `-type:Spell NOT kw:Burst`, `(NOT type:"Spell" AND NOT keyword:"Burst")`),
+		Entry("quoted text", `text:"deal 1" "Mystic \"Shot\""`, `(text:"deal 1" AND "Mystic \"Shot\"")`),
+		Entry("spaces around operators", `cost >= 3`, `cost>="3"`),
+		Entry("minus in words", `Jhin-like`, `"Jhin-like"`),
+	)
+
+	DescribeTable("rejects invalid queries",
+		func(q string, pos int, msg string) {
+			_, err := query.Parse(q)
+			Expect(err).To(Equal(&query.SyntaxError{Pos: pos, Msg: msg}))
+		},
+		Entry("empty", `  `, 1, "empty query"),
+		Entry("unknown field", `colour:red`, 1, `unknown field "colour"`),
+		Entry("not a number", `cost:two`, 6, "cost must be a number"),
+		Entry("compared text", `name<Jhin`, 5, "name can't be compared with <"),
+		Entry("missing value", `cost<=`, 7, "missing value of cost"),
+		Entry("unbalanced", `(a OR b`, 8, "missing )"),
+		Entry("extra parenthesis", `a)`, 2, `unexpected ")"`),
+		Entry("dangling OR", `a OR`, 5, "missing term at the end"),
+		Entry("leading AND", `AND a`, 1, "missing term before AND"),
+		Entry("unterminated quote", `text:"deal`, 6, "unterminated quoted text"),
+	)
+})
+
+var _ = Describe("Match", func() {
+	DescribeTable("matches cards",
+		func(q string, codes ...string) {
+			Expect(matching(q)).To(Equal(codes))
+		},
+		Entry("numbers", `cost<=2`, "01PZ052", "01NX002"),
+		Entry("number equality", `cost:5`, "01NX004"),
+		Entry("number inequality", `attack!=3`, "01NX004", "01PZ052"),
+		Entry("region refs", `region:PiltoverZaun`, "01PZ052"),
+		Entry("localized regions", `region:"piltover y zaun"`, "01PZ052"),
+		Entry("localized keywords", `keyword:rafaga`, "01PZ052"),
+		Entry("keyword refs", `kw:Burst`, "01PZ052"),
+		Entry("types", `type:Spell`, "01NX004", "01PZ052"),
+		Entry("localized formats", `format:eterno`, "01PZ052"),
+		Entry("text contains", `text:"deal 2"`, "01PZ052"),
+		Entry("name equality", `name="mystic shot"`, "01PZ052"),
+		Entry("full text in names and descriptions", `nexus`, "01NX004"),
+		Entry("artists and flavor", `artist:vodka flavor:front`, "01NX002"),
+		Entry("collectible", `collectible:no`, "01NX002"),
+		Entry("or", `cost:1 OR cost:5`, "01NX004", "01NX002"),
+		Entry("not", `region:Noxus -type:Unit`, "01NX004"),
+		Entry("the request example", `cost<=2 region:"Piltover & Zaun" type:Spell keyword:Burst text:"deal 2"`, "01PZ052"),
+	)
+})
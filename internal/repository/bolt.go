@@ -0,0 +1,754 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	bucketBundles    = "bundles"
+	bucketGlobals    = "globals"
+	bucketVersions   = "versions"
+	bucketQuarantine = "quarantine"
+	bucketConfig     = "config"
+	bucketUsers      = "users"
+	bucketDeckBuilds = "deckbuilds"
+)
+
+// ErrDatabaseInUse is returned by OpenBolt when another process, like a
+// running bot, has the database open.
+var ErrDatabaseInUse = errors.New("database in use")
+
+// Bolt stores cards, bundles and guild configurations in a single file,
+// using bbolt. Every save runs in one transaction, so a bundle is either
+// saved with all its cards or not at all.
+type Bolt struct {
+	db *bbolt.DB
+}
+
+var _ Repository = (*Bolt)(nil)
+
+// OpenBolt opens the database file at path, creating it if needed. It fails
+// if the file is already open by another process.
+func OpenBolt(path string) (*Bolt, error) {
+	db, err := bbolt.Open(path, 0o600, &bbolt.Options{Timeout: time.Second})
+	if errors.Is(err, bbolt.ErrTimeout) {
+		return nil, fmt.Errorf("%w: %s", ErrDatabaseInUse, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range []string{bucketBundles, bucketGlobals, bucketVersions, bucketQuarantine, bucketConfig, bucketUsers, bucketDeckBuilds} {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create buckets: %w", err)
+	}
+	return &Bolt{db: db}, nil
+}
+
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
+
+// Backup writes a consistent copy of the database to w, without blocking
+// saves.
+func (b *Bolt) Backup(w io.Writer) (int64, error) {
+	var n int64
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		n, err = tx.WriteTo(w)
+		return err
+	})
+	return n, err
+}
+
+// boltCard is the stored card, like cardWrite. Cards are keyed by code and
+// version key, so the versions of a card are next to each other, sorted from
+// the oldest.
+type boltCard struct {
+	Card       Card
+	Bundle     string
+	VersionKey int
+}
+
+func cardsBucket(locale string) []byte {
+	return []byte(cardCollection(locale))
+}
+
+func cardKey(code string, versionKey int) []byte {
+	return []byte(fmt.Sprintf("%s/%010d", code, versionKey))
+}
+
+func bundleKeyBytes(set string, locale string, versionKey int) []byte {
+	return []byte(fmt.Sprintf("%s/%s/%010d", locale, set, versionKey))
+}
+
+func get[T any](bucket *bbolt.Bucket, key []byte) (*T, error) {
+	if bucket == nil {
+		return nil, nil
+	}
+	data := bucket.Get(key)
+	if data == nil {
+		return nil, nil
+	}
+
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", key, err)
+	}
+	return &v, nil
+}
+
+func put(bucket *bbolt.Bucket, key []byte, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(key, data)
+}
+
+// SaveBundle saves the cards of a bundle under the bundle version, keeping the
+// cards saved from previous versions, and moves the current version of the
+// locale forward. Bundles that are not more recent than the stored ones are
+// only saved when force is set.
+func (b *Bolt) SaveBundle(_ context.Context, bundle *SetBundle, force bool) error {
+	v, err := parseVersion(bundle.Version)
+	if err != nil {
+		return err
+	}
+
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		bundles := tx.Bucket([]byte(bucketBundles))
+		key := bundleKeyBytes(bundle.Set, bundle.Locale, v.key())
+		old, err := get[setBundleWrite](bundles, key)
+		if err != nil {
+			return err
+		}
+
+		if force || old == nil || bundle.LastModified.After(old.LastModified) {
+			cards, err := tx.CreateBucketIfNotExists(cardsBucket(bundle.Locale))
+			if err != nil {
+				return err
+			}
+			for _, c := range bundle.Cards {
+				c.Version = bundle.Version
+				if err := setBoltRefs(tx, bundle.Locale, c); err != nil {
+					return err
+				}
+				if err := put(cards, cardKey(c.CardCode, v.key()), boltCard{Card: *c, Bundle: bundle.Set, VersionKey: v.key()}); err != nil {
+					return fmt.Errorf("failed to save card %s: %w", c.CardCode, err)
+				}
+			}
+
+			if bundle.Locale == refsLocale {
+				if err := copyBoltRefs(tx, cardCodes(bundle.Cards)); err != nil {
+					return fmt.Errorf("failed to update card refs: %w", err)
+				}
+			}
+
+			stored := setBundleWrite{LastModified: bundle.LastModified, ETag: bundle.ETag, Locale: bundle.Locale, Set: bundle.Set, Version: bundle.Version, VersionKey: v.key()}
+			if err := put(bundles, key, stored); err != nil {
+				return err
+			}
+		}
+
+		return advanceBoltVersion(tx, bundle.Locale, v)
+	})
+}
+
+// advanceBoltVersion makes v the current version of the locale, unless a more
+// recent version is already current.
+func advanceBoltVersion(tx *bbolt.Tx, locale string, v version) error {
+	current, err := boltCurrentVersion(tx, locale)
+	if err != nil {
+		return err
+	}
+	if current != nil && current.VersionKey >= v.key() {
+		return nil
+	}
+
+	pointer := versionPointer{Locale: locale, Version: v.String(), VersionKey: v.key()}
+	return put(tx.Bucket([]byte(bucketVersions)), []byte(locale), pointer)
+}
+
+func boltCurrentVersion(tx *bbolt.Tx, locale string) (*versionPointer, error) {
+	return get[versionPointer](tx.Bucket([]byte(bucketVersions)), []byte(locale))
+}
+
+// boltGlobals is the stored core bundle metadata. The metadata fields of
+// Globals are not encoded to JSON, so they are kept next to it.
+type boltGlobals struct {
+	Locale       string
+	Version      string
+	LastModified time.Time
+	Globals      *Globals
+}
+
+// SaveGlobals saves the core bundle metadata of a locale, replacing the stored
+// one when the new bundle is more recent or force is set.
+func (b *Bolt) SaveGlobals(_ context.Context, globals *Globals, force bool) error {
+	v, err := parseVersion(globals.Version)
+	if err != nil {
+		return err
+	}
+
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketGlobals))
+		old, err := get[boltGlobals](bucket, []byte(globals.Locale))
+		if err != nil {
+			return err
+		}
+
+		if !force && old != nil {
+			if c := CompareVersions(old.Version, globals.Version); c > 0 || c == 0 && !globals.LastModified.After(old.LastModified) {
+				return nil
+			}
+		}
+
+		g := boltGlobals{Locale: globals.Locale, Version: globals.Version, LastModified: globals.LastModified, Globals: globals}
+		if err := put(bucket, []byte(globals.Locale), g); err != nil {
+			return fmt.Errorf("failed to save globals: %w", err)
+		}
+		return advanceBoltVersion(tx, globals.Locale, v)
+	})
+}
+
+// FindGlobals returns the stored core bundle metadata of a locale, or nil if
+// it was never saved.
+func (b *Bolt) FindGlobals(_ context.Context, locale string) (*Globals, error) {
+	var globals *Globals
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		g, err := get[boltGlobals](tx.Bucket([]byte(bucketGlobals)), []byte(locale))
+		if err != nil || g == nil {
+			return err
+		}
+
+		globals = g.Globals
+		globals.Locale, globals.Version, globals.LastModified = g.Locale, g.Version, g.LastModified
+		return nil
+	})
+	return globals, err
+}
+
+// FindBundle returns the metadata of the most recent stored bundle of a set
+// and locale, without its cards. It returns nil if the bundle was never saved.
+func (b *Bolt) FindBundle(_ context.Context, set string, locale string) (*SetBundle, error) {
+	var bundle *SetBundle
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		prefix := []byte(locale + "/" + set + "/")
+		c := tx.Bucket([]byte(bucketBundles)).Cursor()
+
+		var latest []byte
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			latest = v
+		}
+		if latest == nil {
+			return nil
+		}
+
+		var stored setBundleWrite
+		if err := json.Unmarshal(latest, &stored); err != nil {
+			return err
+		}
+		bundle = &SetBundle{LastModified: stored.LastModified, ETag: stored.ETag, Locale: stored.Locale, Set: stored.Set, Version: stored.Version}
+		return nil
+	})
+	return bundle, err
+}
+
+// Quarantine saves the cards that failed validation, with the reasons, so
+// they can be inspected. A card quarantined again for the same version is
+// replaced.
+func (b *Bolt) Quarantine(_ context.Context, anomalies []Anomaly) error {
+	if len(anomalies) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketQuarantine))
+		for _, a := range anomalies {
+			key := []byte(strings.Join([]string{a.Locale, a.Set, a.Version, a.CardCode}, "/"))
+			if err := put(bucket, key, anomalyWrite{Anomaly: a, QuarantinedAt: now}); err != nil {
+				return fmt.Errorf("failed to quarantine cards: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// StoredVersion returns the most recent bundle version saved, or an empty
+// string if no bundle was saved yet.
+func (b *Bolt) StoredVersion(_ context.Context) (string, error) {
+	latest := ""
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(bucketBundles)).ForEach(func(_, v []byte) error {
+			var stored setBundleWrite
+			if err := json.Unmarshal(v, &stored); err != nil {
+				return err
+			}
+			if CompareVersions(stored.Version, latest) > 0 {
+				latest = stored.Version
+			}
+			return nil
+		})
+	})
+	return latest, err
+}
+
+// CurrentVersion returns the current version of a locale, which is the most
+// recent version saved for it, or an empty string if nothing was saved.
+func (b *Bolt) CurrentVersion(_ context.Context, locale string) (string, error) {
+	version := ""
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		current, err := boltCurrentVersion(tx, locale)
+		if current != nil {
+			version = current.Version
+		}
+		return err
+	})
+	return version, err
+}
+
+// boltVersionKey returns the key cards are found at, like versionPipeline:
+// the given version, or the current version of the locale if it is empty.
+// Zero means any version.
+func boltVersionKey(tx *bbolt.Tx, locale string, v string) (int, error) {
+	if v != "" {
+		parsed, err := parseVersion(v)
+		if err != nil {
+			return 0, err
+		}
+		return parsed.key(), nil
+	}
+
+	current, err := boltCurrentVersion(tx, locale)
+	if err != nil || current == nil {
+		return 0, err
+	}
+	return current.VersionKey, nil
+}
+
+// eachCard calls fn with the stored versions of each card of a locale, sorted
+// by code.
+func eachCard(tx *bbolt.Tx, locale string, fn func(versions []boltCard) error) error {
+	bucket := tx.Bucket(cardsBucket(locale))
+	if bucket == nil {
+		return nil
+	}
+
+	var versions []boltCard
+	code := ""
+	flush := func() error {
+		if len(versions) == 0 {
+			return nil
+		}
+		err := fn(versions)
+		versions = nil
+		return err
+	}
+
+	c := bucket.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if k := string(k[:bytes.LastIndexByte(k, '/')]); k != code {
+			if err := flush(); err != nil {
+				return err
+			}
+			code = k
+		}
+
+		var card boltCard
+		if err := json.Unmarshal(v, &card); err != nil {
+			return fmt.Errorf("failed to decode card %s: %w", k, err)
+		}
+		versions = append(versions, card)
+	}
+	return flush()
+}
+
+// cardVersions returns the stored versions of a card, sorted from the oldest.
+func cardVersions(tx *bbolt.Tx, locale string, code string) ([]boltCard, error) {
+	bucket := tx.Bucket(cardsBucket(locale))
+	if bucket == nil {
+		return nil, nil
+	}
+
+	var versions []boltCard
+	prefix := []byte(code + "/")
+	c := bucket.Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		var card boltCard
+		if err := json.Unmarshal(v, &card); err != nil {
+			return nil, fmt.Errorf("failed to decode card %s: %w", k, err)
+		}
+		versions = append(versions, card)
+	}
+	return versions, nil
+}
+
+// asOf returns the card saved from the most recent version up to key.
+func asOf(versions []boltCard, key int) *Card {
+	for i := len(versions) - 1; i >= 0; i-- {
+		if key == 0 || versions[i].VersionKey <= key {
+			c := versions[i].Card
+			return &c
+		}
+	}
+	return nil
+}
+
+// setBoltRefs sets the refs of a card before it is saved: en_us cards are
+// their own refs, the others take them from the most recent en_us card.
+func setBoltRefs(tx *bbolt.Tx, locale string, c *Card) error {
+	if locale == refsLocale {
+		refsOf(c).apply(c)
+		return nil
+	}
+
+	en, err := cardVersions(tx, refsLocale, c.CardCode)
+	if err != nil {
+		return err
+	}
+	if latest := asOf(en, 0); latest != nil {
+		refsOf(latest).apply(c)
+	}
+	return nil
+}
+
+// copyBoltRefs copies the refs of the most recent en_us cards to every
+// version of the cards of the other locales with the same codes.
+func copyBoltRefs(tx *bbolt.Tx, codes []string) error {
+	var buckets []*bbolt.Bucket
+	err := tx.ForEach(func(name []byte, bucket *bbolt.Bucket) error {
+		if bytes.HasPrefix(name, cardsBucket("")) && !bytes.Equal(name, cardsBucket(refsLocale)) {
+			buckets = append(buckets, bucket)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, code := range codes {
+		en, err := cardVersions(tx, refsLocale, code)
+		if err != nil {
+			return err
+		}
+		latest := asOf(en, 0)
+		if latest == nil {
+			continue
+		}
+		refs := refsOf(latest)
+
+		prefix := []byte(code + "/")
+		for _, bucket := range buckets {
+			// Keys are collected first, as the bucket can't be changed
+			// while a cursor walks it.
+			var keys [][]byte
+			c := bucket.Cursor()
+			for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+				keys = append(keys, append([]byte(nil), k...))
+			}
+			for _, k := range keys {
+				card, err := get[boltCard](bucket, k)
+				if err != nil {
+					return err
+				}
+				refs.apply(&card.Card)
+				if err := put(bucket, k, card); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// FindCards finds cards by code as they were in the given version, or in the
+// current version of the language if version is empty.
+func (b *Bolt) FindCards(_ context.Context, language string, version string, codes ...string) ([]*Card, error) {
+	var cards []*Card
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		key, err := boltVersionKey(tx, language, version)
+		if err != nil {
+			return err
+		}
+
+		for _, code := range lo.Uniq(codes) {
+			versions, err := cardVersions(tx, language, code)
+			if err != nil {
+				return err
+			}
+			if c := asOf(versions, key); c != nil {
+				cards = append(cards, c)
+			}
+		}
+
+		sortByCode(cards)
+		return nil
+	})
+	return cards, err
+}
+
+// FindSnapshot returns all the cards of a language as they were in the given
+// version: the cards of the most recent bundle of each set saved up to that
+// version.
+func (b *Bolt) FindSnapshot(_ context.Context, language string, version string) ([]*Card, error) {
+	v, err := parseVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	var cards []*Card
+	err = b.db.View(func(tx *bbolt.Tx) error {
+		current, err := boltCurrentVersion(tx, language)
+		if err != nil {
+			return err
+		}
+		if current == nil || v.key() > current.VersionKey {
+			return fmt.Errorf("%w: %s", ErrVersionNotFound, version)
+		}
+
+		latest := make(map[string]int)
+		prefix := []byte(language + "/")
+		c := tx.Bucket([]byte(bucketBundles)).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			parts := strings.Split(string(k), "/")
+			key, err := strconv.Atoi(parts[2])
+			if err != nil {
+				return fmt.Errorf("invalid bundle key %s: %w", k, err)
+			}
+			if key <= v.key() && key > latest[parts[1]] {
+				latest[parts[1]] = key
+			}
+		}
+		if len(latest) == 0 {
+			return fmt.Errorf("%w: %s", ErrVersionNotFound, version)
+		}
+
+		return eachCard(tx, language, func(versions []boltCard) error {
+			for i := len(versions) - 1; i >= 0; i-- {
+				if latest[versions[i].Bundle] == versions[i].VersionKey {
+					c := versions[i].Card
+					cards = append(cards, &c)
+					break
+				}
+			}
+			return nil
+		})
+	})
+	return cards, err
+}
+
+// SearchByName searches cards whose name contains the given name, ignoring
+// case, as they were in the given version, or in the current version of the
+// language if version is empty.
+func (b *Bolt) SearchByName(_ context.Context, language string, version string, name string) ([]*Card, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return nil, nil
+	}
+
+	var cards []*Card
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		key, err := boltVersionKey(tx, language, version)
+		if err != nil {
+			return err
+		}
+
+		err = eachCard(tx, language, func(versions []boltCard) error {
+			if c := asOf(versions, key); c != nil && strings.Contains(strings.ToLower(c.Name), name) {
+				cards = append(cards, c)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		cards = lo.UniqBy(cards, func(c *Card) string {
+			return c.Name
+		})
+		if len(cards) > 25 {
+			cards = cards[:25]
+		}
+		return nil
+	})
+	return cards, err
+}
+
+// GuildConfig returns the settings of a guild, with empty settings if it
+// never changed them.
+func (b *Bolt) GuildConfig(_ context.Context, guild string) (*GuildConfig, error) {
+	config := &GuildConfig{Guild: guild, SchemaVersion: GuildConfigSchema}
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		c, err := get[GuildConfig](tx.Bucket([]byte(bucketConfig)), []byte(guild))
+		if c != nil {
+			config = c
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// UpdateGuildConfig changes the settings of a guild with update and saves
+// them in one transaction.
+func (b *Bolt) UpdateGuildConfig(_ context.Context, guild string, update func(*GuildConfig)) error {
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketConfig))
+		config, err := get[GuildConfig](bucket, []byte(guild))
+		if err != nil {
+			return err
+		}
+		if config == nil {
+			config = &GuildConfig{}
+		}
+
+		update(config)
+		config.Guild, config.SchemaVersion = guild, GuildConfigSchema
+		return put(bucket, []byte(guild), config)
+	})
+}
+
+// UserPrefs returns the preferences of a user, with empty preferences if
+// they never changed them.
+func (b *Bolt) UserPrefs(_ context.Context, user string) (*Preferences, error) {
+	prefs := &Preferences{}
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		p, err := get[Preferences](tx.Bucket([]byte(bucketUsers)), []byte(user))
+		if p != nil {
+			prefs = p
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+// UpdateUserPrefs changes the preferences of a user with update and saves
+// them in one transaction.
+func (b *Bolt) UpdateUserPrefs(_ context.Context, user string, update func(*Preferences)) error {
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketUsers))
+		prefs, err := get[Preferences](bucket, []byte(user))
+		if err != nil {
+			return err
+		}
+		if prefs == nil {
+			prefs = &Preferences{}
+		}
+
+		update(prefs)
+		return put(bucket, []byte(user), prefs)
+	})
+}
+
+// DeckBuild returns the deck a user is building, with no cards if they never
+// saved one.
+func (b *Bolt) DeckBuild(_ context.Context, user string) (*DeckBuild, error) {
+	build := &DeckBuild{User: user}
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		d, err := get[DeckBuild](tx.Bucket([]byte(bucketDeckBuilds)), []byte(user))
+		if d != nil {
+			build = d
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return build, nil
+}
+
+// UpdateDeckBuild changes the deck a user is building with update and saves
+// it in one transaction.
+func (b *Bolt) UpdateDeckBuild(_ context.Context, user string, update func(*DeckBuild)) error {
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketDeckBuilds))
+		build, err := get[DeckBuild](bucket, []byte(user))
+		if err != nil {
+			return err
+		}
+		if build == nil {
+			build = &DeckBuild{}
+		}
+
+		update(build)
+		build.User = user
+		return put(bucket, []byte(user), build)
+	})
+}
+
+// boltMigration upgrades a stored guild configuration, decoded as a JSON
+// object, to a schema version.
+type boltMigration struct {
+	version int
+	migrate func(guild string, doc map[string]any)
+}
+
+var boltMigrations = []boltMigration{
+	{version: 1, migrate: func(guild string, doc map[string]any) {
+		doc["Guild"] = guild
+		doc["URLTemplate"], doc["URLLabel"] = doc["Template"], doc["Label"]
+		delete(doc, "Template")
+		delete(doc, "Label")
+	}},
+}
+
+// Migrate upgrades the guild configurations written by previous versions of
+// the bot.
+func (b *Bolt) Migrate(_ context.Context) error {
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketConfig))
+		upgraded := make(map[string]map[string]any)
+		err := bucket.ForEach(func(k, v []byte) error {
+			var doc map[string]any
+			if err := json.Unmarshal(v, &doc); err != nil {
+				return fmt.Errorf("failed to decode %s: %w", k, err)
+			}
+
+			version, _ := doc["SchemaVersion"].(float64)
+			for _, m := range boltMigrations {
+				if int(version) < m.version {
+					m.migrate(string(k), doc)
+					doc["SchemaVersion"] = m.version
+					upgraded[string(k)] = doc
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for guild, doc := range upgraded {
+			if err := put(bucket, []byte(guild), doc); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,99 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/dneto/sai-scout/internal/repository/repositorytest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.etcd.io/bbolt"
+)
+
+var _ = Describe("Bolt", func() {
+	open := func(path string) *repository.Bolt {
+		GinkgoHelper()
+		repo, err := repository.OpenBolt(path)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(repo.Close)
+		return repo
+	}
+
+	repositorytest.Contract(func() repository.Repository {
+		return open(filepath.Join(GinkgoT().TempDir(), "sai-scout.db"))
+	})
+
+	It("keeps saved data after reopening", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "sai-scout.db")
+		repo, err := repository.OpenBolt(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(repo.UpdateGuildConfig(context.Background(), "guild", func(c *repository.GuildConfig) {
+			c.Language = "pt_br"
+		})).To(Succeed())
+		Expect(repo.Close()).To(Succeed())
+
+		config, err := open(path).GuildConfig(context.Background(), "guild")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config.Language).To(Equal("pt_br"))
+	})
+
+	It("fails to open a database in use", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "sai-scout.db")
+		open(path)
+
+		_, err := repository.OpenBolt(path)
+		Expect(err).To(MatchError(repository.ErrDatabaseInUse))
+	})
+
+	It("backs up the database", func() {
+		dir := GinkgoT().TempDir()
+		repo := open(filepath.Join(dir, "sai-scout.db"))
+		Expect(repo.UpdateGuildConfig(context.Background(), "guild", func(c *repository.GuildConfig) {
+			c.URLTemplate, c.URLLabel = "https://example.com/{{code}}", "Example"
+		})).To(Succeed())
+
+		backup, err := os.Create(filepath.Join(dir, "backup.db"))
+		Expect(err).ToNot(HaveOccurred())
+		n, err := repo.Backup(backup)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).To(BeNumerically(">", 0))
+		Expect(backup.Close()).To(Succeed())
+
+		config, err := open(backup.Name()).GuildConfig(context.Background(), "guild")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config.URLTemplate).To(Equal("https://example.com/{{code}}"))
+		Expect(config.URLLabel).To(Equal("Example"))
+	})
+
+	It("migrates guild configurations of previous versions", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "sai-scout.db")
+		db, err := bbolt.Open(path, 0o600, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(db.Update(func(tx *bbolt.Tx) error {
+			bucket, err := tx.CreateBucketIfNotExists([]byte("config"))
+			if err != nil {
+				return err
+			}
+			return bucket.Put([]byte("guild"), []byte(`{"Language":"pt_br","Template":"https://example.com/{{code}}","Label":"Example"}`))
+		})).To(Succeed())
+		Expect(db.Close()).To(Succeed())
+
+		repo := open(path)
+		Expect(repo.Migrate(context.Background())).To(Succeed())
+		Expect(repo.Migrate(context.Background())).To(Succeed())
+
+		config, err := repo.GuildConfig(context.Background(), "guild")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config).To(Equal(&repository.GuildConfig{
+			Guild:         "guild",
+			SchemaVersion: repository.GuildConfigSchema,
+			Preferences: repository.Preferences{
+				Language:    "pt_br",
+				URLTemplate: "https://example.com/{{code}}",
+				URLLabel:    "Example",
+			},
+		}))
+	})
+})
@@ -37,4 +37,7 @@ type Card struct {
 
 	TypeRef      string
 	SupertypeRef string
+
+	// Version is the bundle version the card was saved from.
+	Version string `json:"version,omitempty"`
 }
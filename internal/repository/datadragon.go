@@ -3,25 +3,103 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/dneto/sai-scout/internal/i18n"
 	"github.com/rs/zerolog/log"
 	"github.com/sourcegraph/conc/pool"
 )
 
 const cdn = "dd.b.pvp.net"
 
+// DefaultDataDragonURL is the base URL of the official Data Dragon CDN.
+const DefaultDataDragonURL = "https://" + cdn
+
+const (
+	defaultTimeout = 30 * time.Second
+	defaultRetries = 3
+	defaultBackoff = 500 * time.Millisecond
+)
+
+// ErrBundleNotFound is returned when a bundle is not published on the CDN.
+var ErrBundleNotFound = errors.New("bundle not found")
+
+var errNotModified = errors.New("bundle not modified")
+
+type DataDragon struct {
+	baseURL string
+	client  *http.Client
+	timeout time.Duration
+	retries int
+	backoff time.Duration
+}
+
+func NewDataDragon(baseURL string, client *http.Client) *DataDragon {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &DataDragon{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  client,
+		timeout: defaultTimeout,
+		retries: defaultRetries,
+		backoff: defaultBackoff,
+	}
+}
+
+// WithTimeout sets the timeout of each request made to the CDN.
+func (dd *DataDragon) WithTimeout(timeout time.Duration) *DataDragon {
+	dd.timeout = timeout
+	return dd
+}
+
+// WithRetries sets how many times requests failing with transient errors are
+// retried. The wait between attempts starts at backoff and doubles each time.
+func (dd *DataDragon) WithRetries(retries int, backoff time.Duration) *DataDragon {
+	dd.retries, dd.backoff = retries, backoff
+	return dd
+}
+
+// UpdateParams selects which bundles are downloaded. Empty Sets are
+// discovered from the core bundle and empty Locales default to all locales.
+type UpdateParams struct {
+	Version string
+	Sets    []string
+	Locales []string
+
+	// FindBundle returns the stored bundle of a set and locale, used to make
+	// conditional requests. Conditional requests are disabled when it is nil
+	// or when Force is set.
+	FindBundle func(ctx context.Context, set string, locale string) (*SetBundle, error)
+	Force      bool
+
+	// Quarantine saves the cards that failed validation. Invalid cards are
+	// only reported when it is nil.
+	Quarantine func(context.Context, []Anomaly) error
+}
+
+func (p UpdateParams) locales() []string {
+	if len(p.Locales) == 0 {
+		return i18n.AsStringSlice(i18n.Locales)
+	}
+	return p.Locales
+}
+
 type downloadAllParams struct {
-	Version   string
+	UpdateParams
 	Sets      []string
 	Languages []string
 }
 
 type SetBundle struct {
 	LastModified time.Time
+	ETag         string
 	Locale       string
 	Set          string
 	Version      string
@@ -30,56 +108,395 @@ type SetBundle struct {
 
 type setBundleWrite struct {
 	LastModified time.Time
+	ETag         string
 	Locale       string
 	Set          string
 	Version      string
+	VersionKey   int
+}
+
+// Outcome is the result of downloading a single bundle.
+type Outcome int
+
+const (
+	// OutcomeUnchanged means the bundle did not change since it was stored.
+	OutcomeUnchanged Outcome = iota
+	// OutcomeUpdated means the bundle was downloaded and saved.
+	OutcomeUpdated
+	// OutcomeFailed means the bundle could not be downloaded or saved.
+	OutcomeFailed
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeUnchanged:
+		return "unchanged"
+	case OutcomeUpdated:
+		return "updated"
+	default:
+		return "failed"
+	}
+}
+
+// DownloadReport summarizes a bundle download run.
+type DownloadReport struct {
+	// Found lists sets published on the CDN that are missing from the
+	// fallback set list.
+	Found []string
+	// Skipped lists sets with no bundles published for the version.
+	Skipped []string
+	// Missing lists the bundles not published for some locales of sets
+	// published for others, by bundle name. The bundles of the other locales
+	// are saved.
+	Missing []string
+	// Failed holds the errors of sets that failed to download or save.
+	Failed map[string]error
+	// Outcomes holds the outcome of each published bundle, by bundle name,
+	// e.g. "set1-en_us".
+	Outcomes map[string]Outcome
+	// Anomalies lists the cards left out of the saved bundles because they
+	// failed validation.
+	Anomalies []Anomaly
+}
+
+// Count returns how many bundles ended with the given outcome.
+func (r *DownloadReport) Count(outcome Outcome) int {
+	count := 0
+	for _, o := range r.Outcomes {
+		if o == outcome {
+			count++
+		}
+	}
+	return count
+}
+
+func (dd *DataDragon) UpdateSetBundles(ctx context.Context, params UpdateParams, saveFunc func(context.Context, *SetBundle) error) (*DownloadReport, error) {
+	setNames := params.Sets
+	if len(setNames) == 0 {
+		discovered, err := dd.Sets(ctx, params.Version)
+		if err != nil {
+			log.Warn().Err(err).Str("version", params.Version).Msg("failed to discover sets, using fallback list")
+			discovered = sets
+		}
+		setNames = discovered
+	}
+
+	report, err := dd.downloadAll(ctx, downloadAllParams{
+		UpdateParams: params,
+		Sets:         setNames,
+		Languages:    params.locales(),
+	}, saveFunc)
+
+	if err != nil {
+		return report, fmt.Errorf("failed to download bundles from cdn: %w", err)
+	}
+
+	return report, nil
+}
+
+// UpdateGlobals downloads the core bundle metadata of the selected locales.
+func (dd *DataDragon) UpdateGlobals(ctx context.Context, params UpdateParams, saveFunc func(context.Context, *Globals) error) error {
+	globalsPool := pool.New().WithErrors().WithMaxGoroutines(10)
+	for _, language := range params.locales() {
+		language := language
+		globalsPool.Go(func() error {
+			globals, err := dd.downloadGlobals(ctx, params.Version, language)
+			if err != nil {
+				return fmt.Errorf("core-%s: %w", language, err)
+			}
+			if err := saveFunc(context.Background(), globals); err != nil {
+				return fmt.Errorf("core-%s: %w", language, err)
+			}
+			return nil
+		})
+	}
+
+	if err := globalsPool.Wait(); err != nil {
+		return fmt.Errorf("failed to download globals from cdn: %w", err)
+	}
+	return nil
+}
+
+// Sets returns the names of the set bundles listed in the core bundle of
+// version.
+func (dd *DataDragon) Sets(ctx context.Context, version string) ([]string, error) {
+	globals, err := dd.downloadGlobals(ctx, version, string(i18n.Default))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(globals.Sets) == 0 {
+		return nil, errors.New("no sets found in globals")
+	}
+
+	names := make([]string, len(globals.Sets))
+	for i, s := range globals.Sets {
+		names[i] = s.BundleName()
+	}
+	return names, nil
+}
+
+// LatestVersion probes the CDN for versions newer than current and returns the
+// most recent one published. If nothing newer is found, current is returned.
+func (dd *DataDragon) LatestVersion(ctx context.Context, current string) (string, error) {
+	latest, err := parseVersion(current)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		found := false
+		for _, candidate := range latest.next() {
+			ok, err := dd.HasVersion(ctx, candidate.String())
+			if err != nil {
+				return "", err
+			}
+			if ok {
+				latest, found = candidate, true
+				break
+			}
+		}
+
+		if !found {
+			return latest.String(), nil
+		}
+	}
+}
+
+// HasVersion reports whether the CDN has published bundles for version.
+func (dd *DataDragon) HasVersion(ctx context.Context, version string) (bool, error) {
+	var found bool
+	err := dd.fetch(ctx, http.MethodHead, dd.globalsURL(version, string(i18n.Default)), nil, func(resp *http.Response) error {
+		switch resp.StatusCode {
+		case http.StatusOK:
+			found = true
+			return nil
+		case http.StatusNotFound, http.StatusForbidden:
+			return nil
+		default:
+			return fmt.Errorf("failed to check version %s: status code %d", version, resp.StatusCode)
+		}
+	})
+
+	return found, err
 }
 
-func downloadAll(ctx context.Context, params downloadAllParams, saveFunc func(context.Context, *SetBundle) error) error {
+func (dd *DataDragon) downloadAll(ctx context.Context, params downloadAllParams, saveFunc func(context.Context, *SetBundle) error) (*DownloadReport, error) {
+	var mu sync.Mutex
+	report := &DownloadReport{Failed: make(map[string]error), Outcomes: make(map[string]Outcome)}
+	published := make(map[string]bool)
+	missing := make(map[string][]string)
+
 	setPool := pool.New().WithErrors().WithMaxGoroutines(10)
 	for _, language := range params.Languages {
 		for _, set := range params.Sets {
+			language, set := language, set
 			setPool.Go(func() error {
-				bundle, err := downloadSetBundle(ctx, params.Version, set, language)
+				outcome, anomalies, err := dd.downloadAndSave(ctx, params.UpdateParams, set, language, saveFunc)
+
+				mu.Lock()
+				defer mu.Unlock()
+				report.Anomalies = append(report.Anomalies, anomalies...)
+				name := fmt.Sprintf("%s-%s", set, language)
+				if errors.Is(err, ErrBundleNotFound) {
+					missing[set] = append(missing[set], name)
+					return nil
+				}
+
+				published[set] = true
+				report.Outcomes[name] = outcome
 				if err != nil {
+					err = fmt.Errorf("%s: %w", name, err)
+					report.Failed[set] = errors.Join(report.Failed[set], err)
 					return err
 				}
-				return saveFunc(context.Background(), bundle)
+				return nil
 			})
 		}
 	}
-	return setPool.Wait()
+	err := setPool.Wait()
+	sortAnomalies(report.Anomalies)
+
+	for _, set := range params.Sets {
+		_, failed := report.Failed[set]
+		switch {
+		case !published[set]:
+			report.Skipped = append(report.Skipped, set)
+		case !failed && !slices.Contains(sets, set):
+			report.Found = append(report.Found, set)
+		}
+		if published[set] {
+			report.Missing = append(report.Missing, missing[set]...)
+		}
+	}
+	slices.Sort(report.Missing)
+
+	return report, err
+}
+
+// downloadAndSave downloads a bundle and saves its valid cards. Invalid cards
+// are quarantined and returned as anomalies.
+func (dd *DataDragon) downloadAndSave(ctx context.Context, params UpdateParams, set string, locale string, saveFunc func(context.Context, *SetBundle) error) (Outcome, []Anomaly, error) {
+	var stored *SetBundle
+	if params.FindBundle != nil && !params.Force {
+		var err error
+		if stored, err = params.FindBundle(ctx, set, locale); err != nil {
+			return OutcomeFailed, nil, fmt.Errorf("failed to find stored bundle: %w", err)
+		}
+	}
+
+	bundle, err := dd.downloadSetBundle(ctx, params.Version, set, locale, stored)
+	if errors.Is(err, errNotModified) {
+		log.Debug().Str("set", set).Str("language", locale).Msg("bundle not modified")
+		return OutcomeUnchanged, nil, nil
+	}
+	if err != nil {
+		return OutcomeFailed, nil, err
+	}
+
+	anomalies := validateBundle(bundle)
+	if len(anomalies) > 0 && params.Quarantine != nil {
+		if err := params.Quarantine(ctx, anomalies); err != nil {
+			return OutcomeFailed, anomalies, fmt.Errorf("failed to quarantine cards: %w", err)
+		}
+	}
+
+	if err := saveFunc(context.Background(), bundle); err != nil {
+		return OutcomeFailed, anomalies, err
+	}
+	return OutcomeUpdated, anomalies, nil
 }
 
-func downloadSetBundle(ctx context.Context, version string, set string, locale string) (*SetBundle, error) {
-	baseURL := fmt.Sprintf("https://%s/%s", cdn, strings.Replace(version, ".", "_", -1))
-	url := baseURL + fmt.Sprintf("/%s/%s/data/%s-%s.json", set, locale, set, locale)
+// downloadSetBundle downloads a set bundle. When stored is given, the request
+// is conditional and errNotModified is returned if the bundle didn't change.
+func (dd *DataDragon) downloadSetBundle(ctx context.Context, version string, set string, locale string, stored *SetBundle) (*SetBundle, error) {
+	url := dd.versionURL(version) + fmt.Sprintf("/%s/%s/data/%s-%s.json", set, locale, set, locale)
+
+	header := http.Header{}
+	if stored != nil {
+		if !stored.LastModified.IsZero() {
+			header.Set("If-Modified-Since", stored.LastModified.UTC().Format(http.TimeFormat))
+		}
+		// ETags are only valid for the same URL, which changes with the version.
+		if stored.ETag != "" && stored.Version == version {
+			header.Set("If-None-Match", stored.ETag)
+		}
+	}
+
+	var bundle *SetBundle
+	err := dd.fetch(ctx, http.MethodGet, url, header, func(resp *http.Response) error {
+		switch resp.StatusCode {
+		case http.StatusOK:
+		case http.StatusNotModified:
+			return errNotModified
+		case http.StatusNotFound, http.StatusForbidden:
+			return ErrBundleNotFound
+		default:
+			return fmt.Errorf("failed to retrieve data: status code %d", resp.StatusCode)
+		}
+
+		var cards []*Card
+		if err := json.NewDecoder(resp.Body).Decode(&cards); err != nil {
+			return err
+		}
 
-	client := http.DefaultClient
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified"))
+		if err != nil {
+			lastModified = time.Now()
+		}
+		bundle = &SetBundle{
+			Cards:        cards,
+			LastModified: lastModified,
+			ETag:         resp.Header.Get("ETag"),
+			Locale:       locale,
+			Set:          set,
+			Version:      version,
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := client.Do(req)
+	log.Debug().Str("set", set).Str("language", locale).Msg("download successful")
+	return bundle, nil
+}
+
+func (dd *DataDragon) downloadGlobals(ctx context.Context, version string, locale string) (*Globals, error) {
+	var globals Globals
+	err := dd.fetch(ctx, http.MethodGet, dd.globalsURL(version, locale), nil, func(resp *http.Response) error {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("failed to retrieve globals: status code %d", resp.StatusCode)
+		}
+
+		if err := json.NewDecoder(resp.Body).Decode(&globals); err != nil {
+			return err
+		}
+
+		lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified"))
+		if err != nil {
+			lastModified = time.Now()
+		}
+		globals.Locale, globals.Version, globals.LastModified = locale, version, lastModified
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode > 400 {
-		return nil, fmt.Errorf("failed to retrieve data: status code %d", resp.StatusCode)
+	log.Debug().Str("language", locale).Msg("globals download successful")
+	return &globals, nil
+}
+
+// fetch sends a request and calls handle with its response, retrying network
+// errors, throttling and server errors with exponential backoff. The response
+// body is closed once handle returns.
+func (dd *DataDragon) fetch(ctx context.Context, method string, url string, header http.Header, handle func(*http.Response) error) error {
+	for attempt := 0; ; attempt++ {
+		retry, err := dd.fetchOnce(ctx, method, url, header, handle)
+		if !retry || attempt >= dd.retries {
+			return err
+		}
+
+		wait := dd.backoff << attempt
+		log.Debug().Err(err).Str("url", url).Dur("wait", wait).Msg("retrying request")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
 	}
+}
 
-	var cards []*Card
-	err = json.NewDecoder(resp.Body).Decode(&cards)
+func (dd *DataDragon) fetchOnce(ctx context.Context, method string, url string, header http.Header, handle func(*http.Response) error) (bool, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, dd.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, method, url, nil)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
-	lastModifiedHeader := resp.Header.Get("Last-Modified")
-	lastModified, err := http.ParseTime(lastModifiedHeader)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := dd.client.Do(req)
 	if err != nil {
-		lastModified = time.Now()
+		return ctx.Err() == nil, err
 	}
-	log.Debug().Str("set", set).Str("language", locale).Msg("download successful")
-	return &SetBundle{Cards: cards, LastModified: lastModified, Locale: locale, Set: set, Version: version}, nil
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+		return true, fmt.Errorf("failed to retrieve data: status code %d", resp.StatusCode)
+	}
+
+	return false, handle(resp)
+}
+
+func (dd *DataDragon) versionURL(version string) string {
+	return fmt.Sprintf("%s/%s", dd.baseURL, strings.Replace(version, ".", "_", -1))
+}
+
+func (dd *DataDragon) globalsURL(version string, locale string) string {
+	return dd.versionURL(version) + fmt.Sprintf("/core/%s/data/globals-%s.json", locale, locale)
 }
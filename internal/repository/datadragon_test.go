@@ -0,0 +1,328 @@
+package repository_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dneto/sai-scout/internal/i18n"
+	"github.com/dneto/sai-scout/internal/repository"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DataDragon", func() {
+	var (
+		ctx = context.Background()
+		cdn *fakeCDN
+		dd  *repository.DataDragon
+	)
+
+	AfterEach(func() {
+		cdn.Close()
+	})
+
+	Context("LatestVersion", func() {
+		BeforeEach(func() {
+			cdn = newFakeCDN("4.10.0", "4.10.1", "4.11.0", "5.0.0", "5.0.1")
+			dd = repository.NewDataDragon(cdn.URL, cdn.Client()).WithRetries(0, 0)
+		})
+
+		It("follows patch, minor and major releases", func() {
+			Expect(dd.LatestVersion(ctx, "4.10.0")).To(Equal("5.0.1"))
+		})
+
+		It("returns current version when nothing newer exists", func() {
+			Expect(dd.LatestVersion(ctx, "5.0.1")).To(Equal("5.0.1"))
+		})
+
+		It("fails on invalid versions", func() {
+			_, err := dd.LatestVersion(ctx, "latest")
+			Expect(err).To(MatchError(`invalid version "latest"`))
+			Expect(err).To(MatchError(repository.ErrInvalidVersion))
+		})
+	})
+
+	Context("HasVersion", func() {
+		BeforeEach(func() {
+			cdn = newFakeCDN("4.10.0")
+			dd = repository.NewDataDragon(cdn.URL, cdn.Client()).WithRetries(0, 0)
+		})
+
+		It("checks the core bundle of the version", func() {
+			Expect(dd.HasVersion(ctx, "4.10.0")).To(BeTrue())
+			Expect(cdn.Requests()).To(Equal([]string{"HEAD /4_10_0/core/en_us/data/globals-en_us.json"}))
+		})
+
+		It("returns false for unpublished versions", func() {
+			Expect(dd.HasVersion(ctx, "4.11.0")).To(BeFalse())
+		})
+	})
+
+	Context("UpdateGlobals", func() {
+		var (
+			mu      sync.Mutex
+			globals []*repository.Globals
+			err     error
+		)
+
+		BeforeEach(func() {
+			globals = nil
+			cdn = newFakeCDN("4.10.0")
+			cdn.Sets = []string{"Set1"}
+			dd = repository.NewDataDragon(cdn.URL, cdn.Client()).WithRetries(0, 0)
+			err = dd.UpdateGlobals(ctx, repository.UpdateParams{Version: "4.10.0"}, func(_ context.Context, g *repository.Globals) error {
+				mu.Lock()
+				defer mu.Unlock()
+				globals = append(globals, g)
+				return nil
+			})
+		})
+
+		It("returns no error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("saves globals of every locale", func() {
+			Expect(globals).To(HaveLen(len(i18n.Locales)))
+			Expect(globals).To(ContainElement(And(
+				HaveField("Locale", "pt_br"),
+				HaveField("Version", "4.10.0"),
+				HaveField("LastModified", lastModified),
+			)))
+		})
+
+		It("finds metadata by ref", func() {
+			set, found := globals[0].Set("set1")
+			Expect(found).To(BeTrue())
+			Expect(set.BundleName()).To(Equal("set1"))
+		})
+	})
+
+	Context("UpdateSetBundles", func() {
+		var (
+			mu      sync.Mutex
+			bundles []*repository.SetBundle
+			report  *repository.DownloadReport
+			err     error
+			save    = func(_ context.Context, b *repository.SetBundle) error {
+				mu.Lock()
+				defer mu.Unlock()
+				bundles = append(bundles, b)
+				return nil
+			}
+		)
+
+		BeforeEach(func() {
+			bundles = nil
+			cdn = newFakeCDN("4.10.0")
+			dd = repository.NewDataDragon(cdn.URL, cdn.Client()).WithRetries(0, 0)
+		})
+
+		Context("sets listed in globals", func() {
+			BeforeEach(func() {
+				cdn.Sets = []string{"Set1", "Set9", "Set10", "SetEvent"}
+				cdn.Published = map[string]bool{"set1": true, "set9": true, "set10": true}
+				cdn.Broken = map[string]bool{"set10": true}
+				cdn.Missing = map[string]bool{"set9-pt_br": true}
+				report, err = dd.UpdateSetBundles(ctx, repository.UpdateParams{Version: "4.10.0"}, save)
+			})
+
+			It("returns the failed bundles", func() {
+				Expect(err).To(MatchError(ContainSubstring("set10-en_us: failed to retrieve data: status code 500")))
+				Expect(report.Failed).To(HaveKey("set10"))
+				Expect(report.Failed).To(HaveLen(1))
+			})
+
+			It("returns the outcome of every published bundle", func() {
+				Expect(report.Outcomes).To(HaveLen(len(i18n.Locales)*3 - 1))
+				Expect(report.Outcomes).To(HaveKeyWithValue("set9-en_us", repository.OutcomeUpdated))
+				Expect(report.Outcomes).To(HaveKeyWithValue("set10-en_us", repository.OutcomeFailed))
+				Expect(report.Count(repository.OutcomeFailed)).To(Equal(len(i18n.Locales)))
+			})
+
+			It("reports sets missing from the fallback list", func() {
+				Expect(report.Found).To(Equal([]string{"set9"}))
+			})
+
+			It("reports sets without bundles", func() {
+				Expect(report.Skipped).To(Equal([]string{"setevent"}))
+			})
+
+			It("reports the bundles missing for some locales", func() {
+				Expect(report.Missing).To(Equal([]string{"set9-pt_br"}))
+			})
+
+			It("saves bundles of discovered sets", func() {
+				Expect(bundles).To(HaveLen(len(i18n.Locales)*2 - 1))
+				Expect(bundles).To(ContainElement(And(HaveField("Set", "set9"), HaveField("Locale", "en_us"))))
+				Expect(bundles).ToNot(ContainElement(And(HaveField("Set", "set9"), HaveField("Locale", "pt_br"))))
+			})
+		})
+
+		Context("selected sets and locales", func() {
+			BeforeEach(func() {
+				cdn.Sets = []string{"Set1", "Set2"}
+				report, err = dd.UpdateSetBundles(ctx, repository.UpdateParams{
+					Version: "4.10.0",
+					Sets:    []string{"set2"},
+					Locales: []string{"pt_br"},
+				}, save)
+			})
+
+			It("returns no error", func() {
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("saves only the selected bundles", func() {
+				Expect(bundles).To(ConsistOf(And(HaveField("Set", "set2"), HaveField("Locale", "pt_br"))))
+			})
+		})
+
+		Context("stored bundles", func() {
+			var stored *repository.SetBundle
+
+			BeforeEach(func() {
+				stored = &repository.SetBundle{Set: "set1", Version: "4.10.0", LastModified: lastModified}
+			})
+
+			JustBeforeEach(func() {
+				report, err = dd.UpdateSetBundles(ctx, repository.UpdateParams{
+					Version: "4.10.0",
+					Sets:    []string{"set1"},
+					Locales: []string{"en_us"},
+					FindBundle: func(_ context.Context, set string, locale string) (*repository.SetBundle, error) {
+						return stored, nil
+					},
+				}, save)
+			})
+
+			It("does not download bundles not modified", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(report.Outcomes).To(Equal(map[string]repository.Outcome{"set1-en_us": repository.OutcomeUnchanged}))
+				Expect(bundles).To(BeEmpty())
+			})
+
+			Context("with an etag of the same version", func() {
+				BeforeEach(func() {
+					stored.LastModified = time.Time{}
+					stored.ETag = `"4_10_0set1"`
+				})
+
+				It("does not download bundles not modified", func() {
+					Expect(report.Outcomes).To(HaveKeyWithValue("set1-en_us", repository.OutcomeUnchanged))
+				})
+			})
+
+			Context("older than the published bundle", func() {
+				BeforeEach(func() {
+					stored.LastModified = lastModified.Add(-time.Hour)
+				})
+
+				It("downloads the bundle", func() {
+					Expect(report.Outcomes).To(HaveKeyWithValue("set1-en_us", repository.OutcomeUpdated))
+					Expect(bundles).To(ConsistOf(HaveField("ETag", `"4_10_0set1"`)))
+				})
+			})
+		})
+
+		Context("forced", func() {
+			BeforeEach(func() {
+				report, err = dd.UpdateSetBundles(ctx, repository.UpdateParams{
+					Version: "4.10.0",
+					Sets:    []string{"set1"},
+					Locales: []string{"en_us"},
+					Force:   true,
+					FindBundle: func(_ context.Context, set string, locale string) (*repository.SetBundle, error) {
+						return &repository.SetBundle{LastModified: lastModified}, nil
+					},
+				}, save)
+			})
+
+			It("downloads bundles not modified", func() {
+				Expect(report.Outcomes).To(HaveKeyWithValue("set1-en_us", repository.OutcomeUpdated))
+				Expect(bundles).To(HaveLen(1))
+			})
+		})
+
+		Context("invalid cards", func() {
+			var quarantined []repository.Anomaly
+
+			BeforeEach(func() {
+				quarantined = nil
+				cdn.Invalid = map[string]bool{"set1": true}
+				report, err = dd.UpdateSetBundles(ctx, repository.UpdateParams{
+					Version: "4.10.0",
+					Sets:    []string{"set1"},
+					Locales: []string{"en_us"},
+					Quarantine: func(_ context.Context, a []repository.Anomaly) error {
+						quarantined = append(quarantined, a...)
+						return nil
+					},
+				}, save)
+			})
+
+			It("saves the valid cards", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(bundles).To(ConsistOf(HaveField("Cards", ConsistOf(HaveField("CardCode", "SET1001")))))
+			})
+
+			It("quarantines and reports the invalid cards", func() {
+				Expect(report.Anomalies).To(ConsistOf(And(
+					HaveField("CardCode", "SET1002"),
+					HaveField("Set", "set1"),
+					HaveField("Locale", "en_us"),
+					HaveField("Version", "4.10.0"),
+					HaveField("Reasons", ConsistOf("missing set", "missing assets", "missing region refs", `unknown rarity ref ""`)),
+				)))
+				Expect(quarantined).To(Equal(report.Anomalies))
+			})
+		})
+
+		Context("transient errors", func() {
+			BeforeEach(func() {
+				dd.WithRetries(2, time.Millisecond)
+				cdn.Flaky = map[string]int{"set1": 2, "set2": 3}
+				report, err = dd.UpdateSetBundles(ctx, repository.UpdateParams{
+					Version: "4.10.0",
+					Sets:    []string{"set1", "set2"},
+					Locales: []string{"en_us"},
+				}, save)
+			})
+
+			It("retries the request", func() {
+				Expect(report.Outcomes).To(HaveKeyWithValue("set1-en_us", repository.OutcomeUpdated))
+			})
+
+			It("fails after the last retry", func() {
+				Expect(report.Outcomes).To(HaveKeyWithValue("set2-en_us", repository.OutcomeFailed))
+				Expect(err).To(MatchError(ContainSubstring("set2-en_us: failed to retrieve data: status code 503")))
+			})
+		})
+
+		Context("globals not available", func() {
+			BeforeEach(func() {
+				report, err = dd.UpdateSetBundles(ctx, repository.UpdateParams{Version: "4.10.0"}, save)
+			})
+
+			It("returns no error", func() {
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("falls back to known sets", func() {
+				Expect(report.Found).To(BeEmpty())
+				Expect(bundles).To(HaveLen(len(i18n.Locales) * 10))
+			})
+
+			It("saves a bundle for every locale", func() {
+				Expect(bundles).To(ContainElement(And(
+					HaveField("Set", "set1"),
+					HaveField("Locale", "pt_br"),
+					HaveField("Version", "4.10.0"),
+					HaveField("LastModified", lastModified),
+					HaveField("Cards", ConsistOf(HaveField("CardCode", "SET1001"))),
+				)))
+			})
+		})
+	})
+})
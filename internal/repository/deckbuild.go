@@ -0,0 +1,22 @@
+package repository
+
+// DeckBuild is the deck a user is building with /deckbuild.
+type DeckBuild struct {
+	User  string          `bson:"user"`
+	Cards []DeckBuildCard `bson:"cards"`
+}
+
+// DeckBuildCard is a card of a deck being built and its number of copies.
+type DeckBuildCard struct {
+	CardCode string `bson:"cardcode"`
+	Count    int    `bson:"count"`
+}
+
+// Clone returns a copy of b with its own cards, so changing it does not
+// change b.
+func (b DeckBuild) Clone() DeckBuild {
+	if b.Cards != nil {
+		b.Cards = append([]DeckBuildCard(nil), b.Cards...)
+	}
+	return b
+}
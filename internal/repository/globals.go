@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"strings"
+	"time"
+)
+
+// Globals holds the localized metadata published in the Data Dragon core
+// bundle.
+type Globals struct {
+	Locale       string    `json:"-"`
+	Version      string    `json:"-"`
+	LastModified time.Time `json:"-"`
+
+	VocabTerms  []VocabTerm  `json:"vocabTerms"`
+	Keywords    []Keyword    `json:"keywords"`
+	Regions     []RegionInfo `json:"regions"`
+	SpellSpeeds []SpellSpeed `json:"spellSpeeds"`
+	Rarities    []Rarity     `json:"rarities"`
+	Sets        []SetInfo    `json:"sets"`
+	Formats     []Format     `json:"formats"`
+}
+
+type VocabTerm struct {
+	Description string `json:"description"`
+	Name        string `json:"name"`
+	NameRef     string `json:"nameRef"`
+}
+
+type Keyword struct {
+	Description string `json:"description"`
+	Name        string `json:"name"`
+	NameRef     string `json:"nameRef"`
+}
+
+type RegionInfo struct {
+	Abbreviation     string `json:"abbreviation"`
+	IconAbsolutePath string `json:"iconAbsolutePath"`
+	Name             string `json:"name"`
+	NameRef          string `json:"nameRef"`
+}
+
+type SpellSpeed struct {
+	Name    string `json:"name"`
+	NameRef string `json:"nameRef"`
+}
+
+type Rarity struct {
+	Name    string `json:"name"`
+	NameRef string `json:"nameRef"`
+}
+
+type SetInfo struct {
+	Name             string `json:"name"`
+	NameRef          string `json:"nameRef"`
+	IconAbsolutePath string `json:"iconAbsolutePath"`
+}
+
+type Format struct {
+	Name             string `json:"name"`
+	NameRef          string `json:"nameRef"`
+	IconAbsolutePath string `json:"iconAbsolutePath"`
+}
+
+// BundleName returns the name used by Data Dragon for the set bundles,
+// e.g. "set6cde" for the "Set6cde" set.
+func (s SetInfo) BundleName() string {
+	return strings.ToLower(s.NameRef)
+}
+
+func (g *Globals) Keyword(ref string) (Keyword, bool) {
+	return findByRef(g.Keywords, ref, func(k Keyword) string { return k.NameRef })
+}
+
+func (g *Globals) VocabTerm(ref string) (VocabTerm, bool) {
+	return findByRef(g.VocabTerms, ref, func(v VocabTerm) string { return v.NameRef })
+}
+
+func (g *Globals) Region(ref string) (RegionInfo, bool) {
+	return findByRef(g.Regions, ref, func(r RegionInfo) string { return r.NameRef })
+}
+
+func (g *Globals) SpellSpeed(ref string) (SpellSpeed, bool) {
+	return findByRef(g.SpellSpeeds, ref, func(s SpellSpeed) string { return s.NameRef })
+}
+
+func (g *Globals) Rarity(ref string) (Rarity, bool) {
+	return findByRef(g.Rarities, ref, func(r Rarity) string { return r.NameRef })
+}
+
+func (g *Globals) Set(ref string) (SetInfo, bool) {
+	return findByRef(g.Sets, ref, func(s SetInfo) string { return s.NameRef })
+}
+
+func (g *Globals) Format(ref string) (Format, bool) {
+	return findByRef(g.Formats, ref, func(f Format) string { return f.NameRef })
+}
+
+func findByRef[T any](items []T, ref string, nameRef func(T) string) (T, bool) {
+	for _, i := range items {
+		if strings.EqualFold(nameRef(i), ref) {
+			return i, true
+		}
+	}
+
+	var zero T
+	return zero, false
+}
@@ -0,0 +1,59 @@
+package repository
+
+// GuildConfigSchema is the version of the GuildConfig documents written by
+// the bot. Documents of previous versions are upgraded by Migrate.
+//
+//   - 0: untyped documents with only the settings saved.
+//   - 1: typed documents, one per guild.
+const GuildConfigSchema = 1
+
+// Preferences are the settings a guild, a channel or a user can choose.
+// Empty settings are left to the next level: a user's preferences win over
+// the ones of the channel, which win over the ones of the guild.
+type Preferences struct {
+	// Language is the language cards are shown in.
+	Language string `bson:"language"`
+	// URLTemplate is the website decks are linked to, with {{code}} in place
+	// of the deck code, and URLLabel its name.
+	URLTemplate string `bson:"template"`
+	URLLabel    string `bson:"label"`
+}
+
+// GuildConfig are the settings of a guild. Empty settings use the defaults of
+// the bot.
+type GuildConfig struct {
+	Guild         string `bson:"guild"`
+	SchemaVersion int    `bson:"schemaversion"`
+	Preferences   `bson:",inline"`
+	// Channels are the preferences of some channels of the guild, by channel
+	// ID.
+	Channels map[string]Preferences `bson:"channels,omitempty"`
+	// GuildLocaleFirst makes the Discord locale of the guild win over the one
+	// of the user when nobody chose a language.
+	GuildLocaleFirst bool `bson:"guildlocalefirst"`
+	// Aliases are the terms the guild uses for some cards.
+	Aliases []Alias `bson:"aliases,omitempty"`
+}
+
+// Alias is a term, like a nickname or an abbreviation, a guild uses for a
+// card.
+type Alias struct {
+	Term     string `bson:"term"`
+	CardCode string `bson:"cardcode"`
+}
+
+// Clone returns a copy of c with its own channel preferences and aliases, so
+// changing it does not change c.
+func (c GuildConfig) Clone() GuildConfig {
+	if c.Channels != nil {
+		channels := make(map[string]Preferences, len(c.Channels))
+		for id, prefs := range c.Channels {
+			channels[id] = prefs
+		}
+		c.Channels = channels
+	}
+	if c.Aliases != nil {
+		c.Aliases = append([]Alias(nil), c.Aliases...)
+	}
+	return c
+}
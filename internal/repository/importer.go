@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	setBundleFile = regexp.MustCompile(`^(set[0-9a-z]+)-([a-z]{2}_[a-z]{2})\.json$`)
+	globalsFile   = regexp.MustCompile(`^globals-([a-z]{2}_[a-z]{2})\.json$`)
+)
+
+// ImportReport is the result of an import.
+type ImportReport struct {
+	// Imported lists the names of the imported bundles, e.g. "set1-en_us".
+	Imported []string
+	// Anomalies lists the cards left out of the imported bundles because
+	// they failed validation.
+	Anomalies []Anomaly
+}
+
+// ImportBundles reads bundles from disk and saves them as if they were
+// downloaded from Data Dragon. Paths can be extracted "setN-xx_xx.json" and
+// "globals-xx_xx.json" files, the official bundle zip archives or directories
+// containing any of them. Invalid cards are saved with quarantine, when it is
+// not nil, instead of being imported.
+func ImportBundles(
+	ctx context.Context,
+	version string,
+	paths []string,
+	saveFunc func(context.Context, *SetBundle) error,
+	saveGlobals func(context.Context, *Globals) error,
+	quarantine func(context.Context, []Anomaly) error,
+) (*ImportReport, error) {
+	importer := &bundleImporter{version: version, save: saveFunc, saveGlobals: saveGlobals, quarantine: quarantine}
+	for _, p := range paths {
+		err := filepath.WalkDir(p, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				importer.importPath(ctx, name, d)
+			}
+			return nil
+		})
+		if err != nil {
+			importer.errs = append(importer.errs, err)
+		}
+	}
+
+	sortAnomalies(importer.report.Anomalies)
+	return &importer.report, errors.Join(importer.errs...)
+}
+
+type bundleImporter struct {
+	version     string
+	save        func(context.Context, *SetBundle) error
+	saveGlobals func(context.Context, *Globals) error
+	quarantine  func(context.Context, []Anomaly) error
+
+	report ImportReport
+	errs   []error
+}
+
+func (bi *bundleImporter) importPath(ctx context.Context, name string, d fs.DirEntry) {
+	if strings.EqualFold(filepath.Ext(name), ".zip") {
+		bi.importZip(ctx, name)
+		return
+	}
+
+	info, err := d.Info()
+	if err != nil {
+		bi.errs = append(bi.errs, fmt.Errorf("%s: %w", name, err))
+		return
+	}
+
+	bi.importFile(ctx, filepath.Base(name), info.ModTime(), func() (io.ReadCloser, error) {
+		return os.Open(name)
+	})
+}
+
+func (bi *bundleImporter) importZip(ctx context.Context, name string) {
+	archive, err := zip.OpenReader(name)
+	if err != nil {
+		bi.errs = append(bi.errs, fmt.Errorf("%s: %w", name, err))
+		return
+	}
+	defer archive.Close()
+
+	for _, f := range archive.File {
+		bi.importFile(ctx, path.Base(f.Name), f.Modified, f.Open)
+	}
+}
+
+// importFile saves the bundle in the file if its name is a known bundle name.
+func (bi *bundleImporter) importFile(ctx context.Context, name string, modified time.Time, open func() (io.ReadCloser, error)) {
+	var err error
+	if m := setBundleFile.FindStringSubmatch(name); m != nil {
+		bundle := &SetBundle{Set: m[1], Locale: m[2], Version: bi.version, LastModified: modified}
+		if err = decodeFile(open, &bundle.Cards); err == nil {
+			err = bi.saveBundle(ctx, bundle)
+		}
+	} else if m := globalsFile.FindStringSubmatch(name); m != nil {
+		globals := &Globals{Locale: m[1], Version: bi.version, LastModified: modified}
+		if err = decodeFile(open, globals); err == nil {
+			err = bi.saveGlobals(ctx, globals)
+		}
+	} else {
+		return
+	}
+
+	bundleName := strings.TrimSuffix(name, ".json")
+	if err != nil {
+		bi.errs = append(bi.errs, fmt.Errorf("%s: %w", bundleName, err))
+		return
+	}
+	bi.report.Imported = append(bi.report.Imported, bundleName)
+}
+
+func (bi *bundleImporter) saveBundle(ctx context.Context, bundle *SetBundle) error {
+	anomalies := validateBundle(bundle)
+	bi.report.Anomalies = append(bi.report.Anomalies, anomalies...)
+	if len(anomalies) > 0 && bi.quarantine != nil {
+		if err := bi.quarantine(ctx, anomalies); err != nil {
+			return fmt.Errorf("failed to quarantine cards: %w", err)
+		}
+	}
+	return bi.save(ctx, bundle)
+}
+
+func decodeFile(open func() (io.ReadCloser, error), v any) error {
+	r, err := open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return json.NewDecoder(r).Decode(v)
+}
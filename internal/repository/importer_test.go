@@ -0,0 +1,149 @@
+package repository_test
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dneto/sai-scout/internal/repository"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ImportBundles", func() {
+	var (
+		ctx     = context.Background()
+		dir     string
+		bundles []*repository.SetBundle
+		globals []*repository.Globals
+		report  *repository.ImportReport
+		err     error
+
+		save = func(_ context.Context, b *repository.SetBundle) error {
+			bundles = append(bundles, b)
+			return nil
+		}
+		saveGlobals = func(_ context.Context, g *repository.Globals) error {
+			globals = append(globals, g)
+			return nil
+		}
+		quarantined []repository.Anomaly
+		quarantine  = func(_ context.Context, a []repository.Anomaly) error {
+			quarantined = append(quarantined, a...)
+			return nil
+		}
+	)
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		bundles, globals, quarantined = nil, nil, nil
+	})
+
+	Context("extracted files", func() {
+		BeforeEach(func() {
+			Expect(os.MkdirAll(filepath.Join(dir, "en_us", "data"), 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "en_us", "data", "set1-en_us.json"), []byte(`[`+validCard("01DE001", "Vanguard Bannerman")+`,{"cardCode":"01DE002","name":"Broken"}]`), 0o644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "en_us", "data", "globals-en_us.json"), []byte(`{"sets":[{"nameRef":"Set1","name":"Foundations"}]}`), 0o644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "README.md"), []byte("readme"), 0o644)).To(Succeed())
+			report, err = repository.ImportBundles(ctx, "4.10.0", []string{dir}, save, saveGlobals, quarantine)
+		})
+
+		It("returns no error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("imports bundles found in directories", func() {
+			Expect(report.Imported).To(ConsistOf("set1-en_us", "globals-en_us"))
+		})
+
+		It("saves set bundles", func() {
+			Expect(bundles).To(ConsistOf(And(
+				HaveField("Set", "set1"),
+				HaveField("Locale", "en_us"),
+				HaveField("Version", "4.10.0"),
+				HaveField("Cards", ConsistOf(HaveField("Name", "Vanguard Bannerman"))),
+			)))
+		})
+
+		It("quarantines invalid cards", func() {
+			Expect(report.Anomalies).To(ConsistOf(And(
+				HaveField("CardCode", "01DE002"),
+				HaveField("Set", "set1"),
+				HaveField("Locale", "en_us"),
+				HaveField("Reasons", ContainElement("missing assets")),
+			)))
+			Expect(quarantined).To(Equal(report.Anomalies))
+		})
+
+		It("saves globals", func() {
+			Expect(globals).To(ConsistOf(And(
+				HaveField("Locale", "en_us"),
+				HaveField("Version", "4.10.0"),
+				HaveField("Sets", HaveLen(1)),
+			)))
+		})
+	})
+
+	Context("zip archives", func() {
+		modified := time.Date(2023, time.September, 1, 12, 0, 0, 0, time.UTC)
+
+		BeforeEach(func() {
+			archive := filepath.Join(dir, "set2-lite-pt_br.zip")
+			f, err := os.Create(archive)
+			Expect(err).ToNot(HaveOccurred())
+			w := zip.NewWriter(f)
+			for name, content := range map[string]string{
+				"pt_br/data/set2-pt_br.json":  "[" + validCard("02BW001", "Gangplank") + "]",
+				"pt_br/img/cards/02BW001.png": "png",
+				"metadata.json":               "{}",
+			} {
+				fw, err := w.CreateHeader(&zip.FileHeader{Name: name, Modified: modified})
+				Expect(err).ToNot(HaveOccurred())
+				_, err = fw.Write([]byte(content))
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(w.Close()).To(Succeed())
+			Expect(f.Close()).To(Succeed())
+
+			report, err = repository.ImportBundles(ctx, "4.10.0", []string{archive}, save, saveGlobals, quarantine)
+		})
+
+		It("returns no error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("saves bundles from the archive", func() {
+			Expect(report.Imported).To(ConsistOf("set2-pt_br"))
+			Expect(bundles).To(ConsistOf(And(
+				HaveField("Set", "set2"),
+				HaveField("Locale", "pt_br"),
+				HaveField("LastModified", BeTemporally("==", modified)),
+				HaveField("Cards", ConsistOf(HaveField("Name", "Gangplank"))),
+			)))
+		})
+	})
+
+	Context("invalid files", func() {
+		BeforeEach(func() {
+			Expect(os.WriteFile(filepath.Join(dir, "set1-en_us.json"), []byte(`{`), 0o644)).To(Succeed())
+			report, err = repository.ImportBundles(ctx, "4.10.0", []string{dir, filepath.Join(dir, "missing")}, save, saveGlobals, quarantine)
+		})
+
+		It("returns the errors of every path", func() {
+			Expect(err).To(MatchError(ContainSubstring("set1-en_us: unexpected EOF")))
+			Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+		})
+
+		It("imports nothing", func() {
+			Expect(report.Imported).To(BeEmpty())
+			Expect(bundles).To(BeEmpty())
+		})
+	})
+})
+
+func validCard(code string, name string) string {
+	return `{"cardCode":"` + code + `","name":"` + name + `","set":"Set1","rarityRef":"Common","regionRefs":["Demacia"],` +
+		`"assets":[{"gameAbsolutePath":"http://dd/` + code + `.png","fullAbsolutePath":"http://dd/` + code + `-full.png"}]}`
+}
@@ -0,0 +1,446 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/samber/lo"
+)
+
+// Memory keeps cards, bundles and guild configurations in memory. It is meant
+// for development and small instances: nothing survives a restart, so the
+// bundles must be loaded again at startup.
+type Memory struct {
+	mu        sync.RWMutex
+	bundles   map[bundleKey]setBundleWrite
+	cards     map[string]map[string][]memoryCard
+	globals   map[string]*Globals
+	current   map[string]version
+	anomalies map[anomalyKey]Anomaly
+	guilds    map[string]GuildConfig
+	users     map[string]Preferences
+	builds    map[string]DeckBuild
+}
+
+var _ Repository = (*Memory)(nil)
+
+type bundleKey struct {
+	set, locale, version string
+}
+
+type anomalyKey struct {
+	code, set, locale, version string
+}
+
+// memoryCard is a card saved from a bundle version, like cardWrite.
+type memoryCard struct {
+	card       Card
+	bundle     string
+	versionKey int
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		bundles:   make(map[bundleKey]setBundleWrite),
+		cards:     make(map[string]map[string][]memoryCard),
+		globals:   make(map[string]*Globals),
+		current:   make(map[string]version),
+		anomalies: make(map[anomalyKey]Anomaly),
+		guilds:    make(map[string]GuildConfig),
+		users:     make(map[string]Preferences),
+		builds:    make(map[string]DeckBuild),
+	}
+}
+
+// SaveBundle saves the cards of a bundle under the bundle version, keeping the
+// cards saved from previous versions, and moves the current version of the
+// locale forward. Bundles that are not more recent than the stored ones are
+// only saved when force is set.
+func (m *Memory) SaveBundle(_ context.Context, bundle *SetBundle, force bool) error {
+	v, err := parseVersion(bundle.Version)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := bundleKey{set: bundle.Set, locale: bundle.Locale, version: bundle.Version}
+	old, ok := m.bundles[key]
+	if force || !ok || bundle.LastModified.After(old.LastModified) {
+		cards := m.cards[bundle.Locale]
+		if cards == nil {
+			cards = make(map[string][]memoryCard)
+			m.cards[bundle.Locale] = cards
+		}
+
+		for _, c := range bundle.Cards {
+			c.Version = bundle.Version
+			if bundle.Locale == refsLocale {
+				refsOf(c).apply(c)
+			} else if en := m.cards[refsLocale][c.CardCode]; len(en) > 0 {
+				refsOf(&en[0].card).apply(c)
+			}
+			cards[c.CardCode] = saveCard(cards[c.CardCode], memoryCard{card: *c, bundle: bundle.Set, versionKey: v.key()})
+		}
+		if bundle.Locale == refsLocale {
+			m.copyRefs(bundle.Cards)
+		}
+
+		m.bundles[key] = setBundleWrite{
+			LastModified: bundle.LastModified,
+			ETag:         bundle.ETag,
+			Locale:       bundle.Locale,
+			Set:          bundle.Set,
+			Version:      bundle.Version,
+			VersionKey:   v.key(),
+		}
+	}
+
+	m.advance(bundle.Locale, v)
+	return nil
+}
+
+// saveCard adds a card to the versions of a card code, sorted from the most
+// recent, replacing the card saved from the same version.
+func saveCard(versions []memoryCard, c memoryCard) []memoryCard {
+	i := sort.Search(len(versions), func(i int) bool {
+		return versions[i].versionKey <= c.versionKey
+	})
+	if i < len(versions) && versions[i].versionKey == c.versionKey {
+		versions[i] = c
+		return versions
+	}
+	return append(versions[:i], append([]memoryCard{c}, versions[i:]...)...)
+}
+
+// copyRefs copies the refs of the most recent en_us cards to the cards of the
+// other locales with the same codes. It must be called with the lock held.
+func (m *Memory) copyRefs(saved []*Card) {
+	for _, c := range saved {
+		en := m.cards[refsLocale][c.CardCode]
+		refs := refsOf(&en[0].card)
+		for locale, cards := range m.cards {
+			if locale == refsLocale {
+				continue
+			}
+			for i := range cards[c.CardCode] {
+				refs.apply(&cards[c.CardCode][i].card)
+			}
+		}
+	}
+}
+
+// advance makes v the current version of the locale, unless a more recent
+// version is already current. It must be called with the lock held.
+func (m *Memory) advance(locale string, v version) {
+	if current, ok := m.current[locale]; !ok || v.key() > current.key() {
+		m.current[locale] = v
+	}
+}
+
+// SaveGlobals saves the core bundle metadata of a locale, replacing the stored
+// one when the new bundle is more recent or force is set.
+func (m *Memory) SaveGlobals(_ context.Context, globals *Globals, force bool) error {
+	v, err := parseVersion(globals.Version)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if old := m.globals[globals.Locale]; !force && old != nil {
+		if c := CompareVersions(old.Version, globals.Version); c > 0 || c == 0 && !globals.LastModified.After(old.LastModified) {
+			return nil
+		}
+	}
+
+	saved := *globals
+	m.globals[globals.Locale] = &saved
+	m.advance(globals.Locale, v)
+	return nil
+}
+
+// FindGlobals returns the stored core bundle metadata of a locale, or nil if
+// it was never saved.
+func (m *Memory) FindGlobals(_ context.Context, locale string) (*Globals, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	globals, ok := m.globals[locale]
+	if !ok {
+		return nil, nil
+	}
+	found := *globals
+	return &found, nil
+}
+
+// FindBundle returns the metadata of the most recent stored bundle of a set
+// and locale, without its cards. It returns nil if the bundle was never saved.
+func (m *Memory) FindBundle(_ context.Context, set string, locale string) (*SetBundle, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var latest *setBundleWrite
+	for _, b := range m.bundles {
+		b := b
+		if b.Set == set && b.Locale == locale && (latest == nil || b.VersionKey > latest.VersionKey) {
+			latest = &b
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+
+	return &SetBundle{
+		LastModified: latest.LastModified,
+		ETag:         latest.ETag,
+		Locale:       latest.Locale,
+		Set:          latest.Set,
+		Version:      latest.Version,
+	}, nil
+}
+
+// Quarantine keeps the cards that failed validation. A card quarantined again
+// for the same version is replaced.
+func (m *Memory) Quarantine(_ context.Context, anomalies []Anomaly) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, a := range anomalies {
+		m.anomalies[anomalyKey{code: a.CardCode, set: a.Set, locale: a.Locale, version: a.Version}] = a
+	}
+	return nil
+}
+
+// StoredVersion returns the most recent bundle version saved, or an empty
+// string if no bundle was saved yet.
+func (m *Memory) StoredVersion(_ context.Context) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	latest := ""
+	for _, b := range m.bundles {
+		if CompareVersions(b.Version, latest) > 0 {
+			latest = b.Version
+		}
+	}
+	return latest, nil
+}
+
+// CurrentVersion returns the current version of a locale, which is the most
+// recent version saved for it, or an empty string if nothing was saved.
+func (m *Memory) CurrentVersion(_ context.Context, locale string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	current, ok := m.current[locale]
+	if !ok {
+		return "", nil
+	}
+	return current.String(), nil
+}
+
+// versionKey returns the key cards are found at, like versionPipeline: the
+// given version, or the current version of the locale if it is empty. Zero
+// means any version. It must be called with the lock held.
+func (m *Memory) versionKey(locale string, v string) (int, error) {
+	if v != "" {
+		parsed, err := parseVersion(v)
+		if err != nil {
+			return 0, err
+		}
+		return parsed.key(), nil
+	}
+
+	if current, ok := m.current[locale]; ok {
+		return current.key(), nil
+	}
+	return 0, nil
+}
+
+// find returns the card saved from the most recent version up to key. It must
+// be called with the lock held.
+func (m *Memory) find(locale string, code string, key int) *Card {
+	for _, c := range m.cards[locale][code] {
+		if key == 0 || c.versionKey <= key {
+			found := c.card
+			return &found
+		}
+	}
+	return nil
+}
+
+func sortByCode(cards []*Card) []*Card {
+	sort.Slice(cards, func(i, j int) bool {
+		return cards[i].CardCode < cards[j].CardCode
+	})
+	return cards
+}
+
+// FindCards finds cards by code as they were in the given version, or in the
+// current version of the language if version is empty.
+func (m *Memory) FindCards(_ context.Context, language string, version string, codes ...string) ([]*Card, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	key, err := m.versionKey(language, version)
+	if err != nil {
+		return nil, err
+	}
+
+	var cards []*Card
+	for _, code := range lo.Uniq(codes) {
+		if c := m.find(language, code, key); c != nil {
+			cards = append(cards, c)
+		}
+	}
+	return sortByCode(cards), nil
+}
+
+// FindSnapshot returns all the cards of a language as they were in the given
+// version: the cards of the most recent bundle of each set saved up to that
+// version.
+func (m *Memory) FindSnapshot(_ context.Context, language string, version string) ([]*Card, error) {
+	v, err := parseVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	current, ok := m.current[language]
+	if !ok || v.key() > current.key() {
+		return nil, fmt.Errorf("%w: %s", ErrVersionNotFound, version)
+	}
+
+	latest := make(map[string]int)
+	for _, b := range m.bundles {
+		if b.Locale == language && b.VersionKey <= v.key() && b.VersionKey > latest[b.Set] {
+			latest[b.Set] = b.VersionKey
+		}
+	}
+	if len(latest) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrVersionNotFound, version)
+	}
+
+	var cards []*Card
+	for _, versions := range m.cards[language] {
+		for _, c := range versions {
+			if latest[c.bundle] == c.versionKey {
+				found := c.card
+				cards = append(cards, &found)
+				break
+			}
+		}
+	}
+	return sortByCode(cards), nil
+}
+
+// SearchByName searches cards whose name contains the given name, ignoring
+// case, as they were in the given version, or in the current version of the
+// language if version is empty.
+func (m *Memory) SearchByName(_ context.Context, language string, version string, name string) ([]*Card, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	key, err := m.versionKey(language, version)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	var cards []*Card
+	for code := range m.cards[language] {
+		c := m.find(language, code, key)
+		if c != nil && name != "" && strings.Contains(strings.ToLower(c.Name), name) {
+			cards = append(cards, c)
+		}
+	}
+
+	cards = lo.UniqBy(sortByCode(cards), func(c *Card) string {
+		return c.Name
+	})
+	if len(cards) > 25 {
+		cards = cards[:25]
+	}
+	return cards, nil
+}
+
+// GuildConfig returns the settings of a guild, with empty settings if it
+// never changed them.
+func (m *Memory) GuildConfig(_ context.Context, guild string) (*GuildConfig, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	config, ok := m.guilds[guild]
+	if !ok {
+		config = GuildConfig{Guild: guild, SchemaVersion: GuildConfigSchema}
+	}
+	config = config.Clone()
+	return &config, nil
+}
+
+// UpdateGuildConfig changes the settings of a guild with update and saves
+// them.
+func (m *Memory) UpdateGuildConfig(_ context.Context, guild string, update func(*GuildConfig)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	config := m.guilds[guild].Clone()
+	update(&config)
+	config.Guild, config.SchemaVersion = guild, GuildConfigSchema
+	m.guilds[guild] = config
+	return nil
+}
+
+// UserPrefs returns the preferences of a user, with empty preferences if
+// they never changed them.
+func (m *Memory) UserPrefs(_ context.Context, user string) (*Preferences, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	prefs := m.users[user]
+	return &prefs, nil
+}
+
+// UpdateUserPrefs changes the preferences of a user with update and saves
+// them.
+func (m *Memory) UpdateUserPrefs(_ context.Context, user string, update func(*Preferences)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prefs := m.users[user]
+	update(&prefs)
+	m.users[user] = prefs
+	return nil
+}
+
+// DeckBuild returns the deck a user is building, with no cards if they never
+// saved one.
+func (m *Memory) DeckBuild(_ context.Context, user string) (*DeckBuild, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	build := m.builds[user].Clone()
+	build.User = user
+	return &build, nil
+}
+
+// UpdateDeckBuild changes the deck a user is building with update and saves
+// it.
+func (m *Memory) UpdateDeckBuild(_ context.Context, user string, update func(*DeckBuild)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	build := m.builds[user].Clone()
+	update(&build)
+	build.User = user
+	m.builds[user] = build
+	return nil
+}
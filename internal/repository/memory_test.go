@@ -0,0 +1,13 @@
+package repository_test
+
+import (
+	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/dneto/sai-scout/internal/repository/repositorytest"
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("Memory", func() {
+	repositorytest.Contract(func() repository.Repository {
+		return repository.NewMemory()
+	})
+})
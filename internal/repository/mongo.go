@@ -5,278 +5,954 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
-	"github.com/dneto/sai-scout/internal/i18n"
 	"github.com/samber/lo"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// sets is used when the set list can't be retrieved from the core bundle.
 var sets = []string{"set1", "set2", "set3", "set4", "set5", "set6", "set6cde", "set7", "set7b", "set8"}
 
 const (
-	collectionBundles = "bundles"
-	collectionCards   = "cards"
-	database          = "sai_scout"
+	collectionBundles    = "bundles"
+	collectionCards      = "cards"
+	collectionGlobals    = "globals"
+	collectionQuarantine = "quarantine"
+	collectionVersions   = "versions"
+	collectionConfig     = "config"
+	collectionUsers      = "users"
+	collectionDeckBuilds = "deckbuilds"
+
+	// DefaultDatabase is the database used by the bot.
+	DefaultDatabase = "sai_scout"
 )
 
-func UpdateSetBundles(ctx context.Context, bundleVersion string, saveFunc func(context.Context, *SetBundle) error) error {
-	err := downloadAll(ctx, downloadAllParams{
-		Version:   bundleVersion,
-		Sets:      sets,
-		Languages: i18n.AsStringSlice(i18n.Locales),
-	}, saveFunc)
+// Mongo stores cards, bundles and guild configurations in MongoDB.
+type Mongo struct {
+	db *mongo.Database
+}
 
-	if err != nil {
-		return fmt.Errorf("failed to download bundles from cdn: %w", err)
-	}
+var _ Repository = (*Mongo)(nil)
 
-	return nil
+func NewMongo(db *mongo.Database) *Mongo {
+	return &Mongo{db: db}
+}
+
+// cardWrite is the stored card document. Cards are saved once per bundle
+// version, so versionkey is used to find the card as of a given version.
+type cardWrite struct {
+	Card       `bson:",inline"`
+	Bundle     string
+	VersionKey int
 }
 
+// ErrVersionNotFound is returned when no bundle was saved up to a version.
+var ErrVersionNotFound = errors.New("version not found")
+
 func createIndexes(ctx context.Context, coll *mongo.Collection) error {
 	_, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "name", Value: "text"}}})
 	if err != nil {
 		return err
 	}
-	_, err = coll.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "cardcode", Value: 1}}})
+	_, err = coll.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{
+		{Key: "cardcode", Value: 1},
+		{Key: "versionkey", Value: -1},
+	}})
+	if err != nil {
+		return err
+	}
+	_, err = coll.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{
+		{Key: "bundle", Value: 1},
+		{Key: "versionkey", Value: 1},
+	}})
 	return err
 }
 
-func InsertBuilder(cli *mongo.Client) func(context.Context, *SetBundle) error {
-	db := cli.Database(database)
-	return func(ctx context.Context, bundle *SetBundle) error {
-		bundleCollection := db.Collection(collectionBundles)
+// SaveBundle saves the cards of a bundle under the bundle version, keeping the
+// cards saved from previous versions, and moves the current version of the
+// locale forward. Bundles that are not more recent than the stored ones are
+// only saved when force is set.
+func (m *Mongo) SaveBundle(ctx context.Context, bundle *SetBundle, force bool) error {
+	v, err := parseVersion(bundle.Version)
+	if err != nil {
+		return err
+	}
 
-		setFilter := bson.D{
-			{Key: "set", Value: bundle.Set},
-			{Key: "language", Value: bundle.Locale},
-		}
+	bundleCollection := m.db.Collection(collectionBundles)
 
-		var oldBundle *SetBundle
-		if err := bundleCollection.FindOne(ctx, setFilter).Decode(&oldBundle); err != nil {
-			if !errors.Is(err, mongo.ErrNoDocuments) {
-				return err
-			}
+	setFilter := bson.D{
+		{Key: "set", Value: bundle.Set},
+		{Key: "locale", Value: bundle.Locale},
+		{Key: "version", Value: bundle.Version},
+	}
+
+	var oldBundle *SetBundle
+	if err := bundleCollection.FindOne(ctx, setFilter).Decode(&oldBundle); err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
 		}
+	}
 
-		if oldBundle == nil || bundle.LastModified.After(oldBundle.LastModified) {
-			opts := options.Replace().SetUpsert(true)
-			b := setBundleWrite{LastModified: bundle.LastModified, Locale: bundle.Locale, Set: bundle.Set, Version: bundle.Version}
-			if _, err := bundleCollection.ReplaceOne(ctx, setFilter, b, opts); err != nil {
-				return err
-			}
+	if force || oldBundle == nil || bundle.LastModified.After(oldBundle.LastModified) {
+		if err := m.setRefs(ctx, bundle); err != nil {
+			return fmt.Errorf("failed to retrieve card refs: %w", err)
+		}
 
-			replaceOnes := make([]mongo.WriteModel, len(bundle.Cards))
-			for i, c := range bundle.Cards {
-				cardFilter := bson.D{
-					{Key: "cardcode", Value: c.CardCode},
-				}
-				replaceOnes[i] = mongo.NewReplaceOneModel().
-					SetFilter(cardFilter).
-					SetReplacement(c).
-					SetUpsert(true)
+		replaceOnes := make([]mongo.WriteModel, len(bundle.Cards))
+		for i, c := range bundle.Cards {
+			c.Version = bundle.Version
+			cardFilter := bson.D{
+				{Key: "cardcode", Value: c.CardCode},
+				{Key: "version", Value: bundle.Version},
 			}
+			replaceOnes[i] = mongo.NewReplaceOneModel().
+				SetFilter(cardFilter).
+				SetReplacement(cardWrite{Card: *c, Bundle: bundle.Set, VersionKey: v.key()}).
+				SetUpsert(true)
+		}
 
-			cardsCollection := db.Collection(cardCollection(bundle.Locale))
-			if err := createIndexes(ctx, cardsCollection); err != nil {
-				return fmt.Errorf("failed to create index: %w", err)
-			}
+		cardsCollection := m.db.Collection(cardCollection(bundle.Locale))
+		if err := createIndexes(ctx, cardsCollection); err != nil {
+			return fmt.Errorf("failed to create index: %w", err)
+		}
 
+		if len(replaceOnes) > 0 {
 			if _, err := cardsCollection.BulkWrite(ctx, replaceOnes); err != nil {
 				return fmt.Errorf("failed to upsert cards: %w", err)
 			}
 		}
+
+		// Bundles of different locales are saved concurrently, so the refs
+		// are copied again once the cards are stored: whichever of the
+		// en_us and the localized cards is saved last sees the other.
+		if err := m.copyRefs(ctx, bundle); err != nil {
+			return fmt.Errorf("failed to update card refs: %w", err)
+		}
+
+		// The bundle is saved after its cards so a failed write is retried
+		// on the next sync instead of being reported as unchanged.
+		opts := options.Replace().SetUpsert(true)
+		b := setBundleWrite{LastModified: bundle.LastModified, ETag: bundle.ETag, Locale: bundle.Locale, Set: bundle.Set, Version: bundle.Version, VersionKey: v.key()}
+		if _, err := bundleCollection.ReplaceOne(ctx, setFilter, b, opts); err != nil {
+			return err
+		}
+	}
+
+	return advanceVersion(ctx, m.db, bundle.Locale, v)
+}
+
+// setRefs sets the refs of the bundle cards before they are saved: en_us
+// cards are their own refs, the others take them from the stored en_us cards.
+func (m *Mongo) setRefs(ctx context.Context, bundle *SetBundle) error {
+	if bundle.Locale == refsLocale {
+		for _, c := range bundle.Cards {
+			refsOf(c).apply(c)
+		}
 		return nil
 	}
+
+	refs, err := m.findRefs(ctx, cardCodes(bundle.Cards))
+	if err != nil {
+		return err
+	}
+	for _, c := range bundle.Cards {
+		refs[c.CardCode].apply(c)
+	}
+	return nil
 }
 
-func FindCardsBuilder(cli *mongo.Client) func(context.Context, string, ...string) ([]*Card, error) {
-	db := cli.Database(database)
-	return func(ctx context.Context, language string, codes ...string) ([]*Card, error) {
-		inCards := make(bson.A, len(codes))
-		for i, c := range codes {
-			inCards[i] = c
+// copyRefs updates the refs of the saved cards: the refs of saved en_us cards
+// are copied to the cards of the other locales, and localized cards saved
+// without refs take them from en_us cards saved in the meantime.
+func (m *Mongo) copyRefs(ctx context.Context, bundle *SetBundle) error {
+	if bundle.Locale != refsLocale {
+		missing := lo.FilterMap(bundle.Cards, func(c *Card, _ int) (string, bool) {
+			return c.CardCode, c.TypeRef == ""
+		})
+		if len(missing) == 0 {
+			return nil
 		}
-		pipeline := bson.A{
-			bson.D{{
-				Key: "$match", Value: bson.D{{
-					Key: "cardcode", Value: bson.D{{
-						Key:   "$in",
-						Value: inCards,
-					}},
-				}},
-			}},
+		refs, err := m.findRefs(ctx, missing)
+		if err != nil {
+			return err
 		}
-		for _, i := range customFieldsPipeline() {
-			pipeline = append(pipeline, i)
+		return updateRefs(ctx, m.db.Collection(cardCollection(bundle.Locale)), refs)
+	}
+
+	refs, err := m.findRefs(ctx, cardCodes(bundle.Cards))
+	if err != nil {
+		return err
+	}
+	names, err := m.localizedCollections(ctx)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := updateRefs(ctx, m.db.Collection(name), refs); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		coll := db.Collection(cardCollection(language))
-		c, err := coll.Aggregate(ctx, pipeline)
+// BackfillRefs sets the refs of cards saved before they were stored on each
+// card. Those cards were written with empty refs, as cardWrite inlines every
+// field of Card. It does nothing when every card already has them.
+func (m *Mongo) BackfillRefs(ctx context.Context) error {
+	missing := bson.D{{Key: "typeref", Value: bson.D{{Key: "$in", Value: bson.A{nil, ""}}}}}
+
+	en := m.db.Collection(cardCollection(refsLocale))
+	_, err := en.UpdateMany(ctx, missing, bson.A{
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "typeref", Value: "$type"},
+			{Key: "supertyperef", Value: "$supertype"},
+		}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update en_us refs: %w", err)
+	}
+
+	names, err := m.localizedCollections(ctx)
+	if err != nil {
+		return err
+	}
+	var refs map[string]cardRefs
+	for _, name := range names {
+		coll := m.db.Collection(name)
+		n, err := coll.CountDocuments(ctx, missing, options.Count().SetLimit(1))
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if n == 0 {
+			continue
+		}
+		if refs == nil {
+			if refs, err = m.findRefs(ctx, nil); err != nil {
+				return err
+			}
+		}
+		if err := updateRefs(ctx, coll, refs); err != nil {
+			return fmt.Errorf("failed to update %s refs: %w", name, err)
 		}
-		var cards []*Card
-		err = c.All(ctx, &cards)
-		return cards, err
 	}
+	return nil
 }
 
-func SearchByNameBuilder(cli *mongo.Client) func(context.Context, string, string) ([]*Card, error) {
-	db := cli.Database(database)
-	return func(ctx context.Context, language string, name string) ([]*Card, error) {
-		if len(strings.Split(name, " ")) > 1 {
-			name = fmt.Sprintf(`"%s"`, name)
+// BackfillBundles sets the bundle of cards saved before it was stored on each
+// card, which FindSnapshot needs, from the set of the card: bundles are named
+// like the sets of their cards in lower case. It does nothing when every card
+// already has it.
+func (m *Mongo) BackfillBundles(ctx context.Context) error {
+	names, err := m.cardCollections(ctx)
+	if err != nil {
+		return err
+	}
+
+	missing := bson.D{{Key: "bundle", Value: bson.D{{Key: "$in", Value: bson.A{nil, ""}}}}}
+	for _, name := range names {
+		_, err := m.db.Collection(name).UpdateMany(ctx, missing, bson.A{
+			bson.D{{Key: "$set", Value: bson.D{{Key: "bundle", Value: bson.D{{Key: "$toLower", Value: "$set"}}}}}},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update %s bundles: %w", name, err)
 		}
-		pipeline := bson.A{
-			bson.D{{Key: "$match",
-				Value: bson.D{{
-					Key: "$text", Value: bson.D{{
-						Key: "$search", Value: name,
-					}},
-				}},
-			}},
-			bson.D{{Key: "$limit", Value: 25}},
+	}
+	return nil
+}
+
+// findRefs returns the refs of the most recent en_us card of each code, or
+// of every code when codes is nil.
+func (m *Mongo) findRefs(ctx context.Context, codes []string) (map[string]cardRefs, error) {
+	var pipeline bson.A
+	if codes != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.D{
+			{Key: "cardcode", Value: bson.D{{Key: "$in", Value: codes}}},
+		}}})
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "versionkey", Value: -1}}}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$cardcode"},
+			{Key: "typeref", Value: bson.D{{Key: "$first", Value: "$type"}}},
+			{Key: "supertyperef", Value: bson.D{{Key: "$first", Value: "$supertype"}}},
+		}}},
+	)
+
+	c, err := m.db.Collection(cardCollection(refsLocale)).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var found []struct {
+		Code         string `bson:"_id"`
+		TypeRef      string `bson:"typeref"`
+		SupertypeRef string `bson:"supertyperef"`
+	}
+	if err := c.All(ctx, &found); err != nil {
+		return nil, err
+	}
+
+	refs := make(map[string]cardRefs, len(found))
+	for _, f := range found {
+		refs[f.Code] = cardRefs{TypeRef: f.TypeRef, SupertypeRef: f.SupertypeRef}
+	}
+	return refs, nil
+}
+
+// cardCollections returns the card collections of every locale.
+func (m *Mongo) cardCollections(ctx context.Context) ([]string, error) {
+	return m.db.ListCollectionNames(ctx, bson.D{
+		{Key: "name", Value: bson.D{{Key: "$regex", Value: "^" + collectionCards + "_"}}},
+	})
+}
+
+// localizedCollections returns the card collections of every locale but
+// en_us.
+func (m *Mongo) localizedCollections(ctx context.Context) ([]string, error) {
+	names, err := m.cardCollections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Without(names, cardCollection(refsLocale)), nil
+}
+
+func updateRefs(ctx context.Context, coll *mongo.Collection, refs map[string]cardRefs) error {
+	updates := make([]mongo.WriteModel, 0, len(refs))
+	for code, r := range refs {
+		updates = append(updates, mongo.NewUpdateManyModel().
+			SetFilter(bson.D{{Key: "cardcode", Value: code}}).
+			SetUpdate(bson.D{{Key: "$set", Value: r}}))
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	_, err := coll.BulkWrite(ctx, updates, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+type anomalyWrite struct {
+	Anomaly       `bson:",inline"`
+	QuarantinedAt time.Time
+}
+
+// Quarantine saves the cards that failed validation, with the reasons,
+// so they can be inspected. A card quarantined again for the same version is
+// replaced.
+func (m *Mongo) Quarantine(ctx context.Context, anomalies []Anomaly) error {
+	if len(anomalies) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	replaceOnes := make([]mongo.WriteModel, len(anomalies))
+	for i, a := range anomalies {
+		filter := bson.D{
+			{Key: "cardcode", Value: a.CardCode},
+			{Key: "set", Value: a.Set},
+			{Key: "locale", Value: a.Locale},
+			{Key: "version", Value: a.Version},
 		}
+		replaceOnes[i] = mongo.NewReplaceOneModel().
+			SetFilter(filter).
+			SetReplacement(anomalyWrite{Anomaly: a, QuarantinedAt: now}).
+			SetUpsert(true)
+	}
 
-		for _, i := range customFieldsPipeline() {
-			pipeline = append(pipeline, i)
+	if _, err := m.db.Collection(collectionQuarantine).BulkWrite(ctx, replaceOnes); err != nil {
+		return fmt.Errorf("failed to quarantine cards: %w", err)
+	}
+	return nil
+}
+
+// SaveGlobals saves the core bundle metadata of a locale, replacing the stored
+// one when the new bundle is more recent or force is set.
+func (m *Mongo) SaveGlobals(ctx context.Context, globals *Globals, force bool) error {
+	coll := m.db.Collection(globalsCollection(globals.Locale))
+
+	var old *Globals
+	if err := coll.FindOne(ctx, bson.D{}).Decode(&old); err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+	}
+
+	v, err := parseVersion(globals.Version)
+	if err != nil {
+		return err
+	}
+
+	if !force && old != nil {
+		if c := CompareVersions(old.Version, globals.Version); c > 0 || c == 0 && !globals.LastModified.After(old.LastModified) {
+			return nil
+		}
+	}
+
+	opts := options.Replace().SetUpsert(true)
+	if _, err := coll.ReplaceOne(ctx, bson.D{}, globals, opts); err != nil {
+		return fmt.Errorf("failed to upsert globals: %w", err)
+	}
+	return advanceVersion(ctx, m.db, globals.Locale, v)
+}
+
+// FindGlobals returns the stored core bundle metadata of a locale, or nil if
+// it was never saved.
+func (m *Mongo) FindGlobals(ctx context.Context, language string) (*Globals, error) {
+	var globals *Globals
+	err := m.db.Collection(globalsCollection(language)).FindOne(ctx, bson.D{}).Decode(&globals)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve globals: %w", err)
+	}
+	return globals, nil
+}
+
+// FindBundle returns the metadata of the most recent stored bundle of a
+// set and locale, without its cards. It returns nil if the bundle was never
+// saved.
+func (m *Mongo) FindBundle(ctx context.Context, set string, locale string) (*SetBundle, error) {
+	var bundle *SetBundle
+	opts := options.FindOne().SetSort(bson.D{{Key: "versionkey", Value: -1}})
+	err := m.db.Collection(collectionBundles).FindOne(ctx, bson.D{
+		{Key: "set", Value: set},
+		{Key: "locale", Value: locale},
+	}, opts).Decode(&bundle)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	return bundle, err
+}
+
+// StoredVersion returns the most recent bundle version saved in the
+// database, or an empty string if no bundle was saved yet.
+func (m *Mongo) StoredVersion(ctx context.Context) (string, error) {
+	versions, err := m.db.Collection(collectionBundles).Distinct(ctx, "version", bson.D{})
+	if err != nil {
+		return "", err
+	}
+
+	latest := ""
+	for _, v := range versions {
+		if s, ok := v.(string); ok && CompareVersions(s, latest) > 0 {
+			latest = s
 		}
+	}
+	return latest, nil
+}
+
+// CurrentVersion returns the current version of a locale, which is the
+// most recent version saved for it, or an empty string if nothing was saved.
+func (m *Mongo) CurrentVersion(ctx context.Context, locale string) (string, error) {
+	current, err := currentVersion(ctx, m.db, locale)
+	if err != nil || current == nil {
+		return "", err
+	}
+	return current.Version, nil
+}
+
+type versionPointer struct {
+	Locale     string
+	Version    string
+	VersionKey int
+}
+
+func currentVersion(ctx context.Context, db *mongo.Database, locale string) (*versionPointer, error) {
+	var current *versionPointer
+	err := db.Collection(collectionVersions).FindOne(ctx, bson.D{{Key: "locale", Value: locale}}).Decode(&current)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve current version: %w", err)
+	}
+	return current, nil
+}
+
+// advanceVersion makes v the current version of the locale, unless a more
+// recent version is already current.
+func advanceVersion(ctx context.Context, db *mongo.Database, locale string, v version) error {
+	current, err := currentVersion(ctx, db, locale)
+	if err != nil {
+		return err
+	}
+	if current != nil && current.VersionKey >= v.key() {
+		return nil
+	}
 
-		coll := db.Collection(cardCollection(language))
-		c, err := coll.Aggregate(ctx, pipeline)
+	filter := bson.D{{Key: "locale", Value: locale}}
+	pointer := versionPointer{Locale: locale, Version: v.String(), VersionKey: v.key()}
+	if _, err := db.Collection(collectionVersions).ReplaceOne(ctx, filter, pointer, options.Replace().SetUpsert(true)); err != nil {
+		return fmt.Errorf("failed to update current version: %w", err)
+	}
+	return nil
+}
+
+// versionPipeline returns the stages that keep, for each card code, the card
+// saved from the most recent version up to the given one. An empty version
+// means the current version of the locale.
+func versionPipeline(ctx context.Context, db *mongo.Database, language string, v string) ([]bson.D, error) {
+	key := 0
+	if v != "" {
+		parsed, err := parseVersion(v)
 		if err != nil {
-			return nil, fmt.Errorf("failed to retrieve card: %w", err)
+			return nil, err
 		}
+		key = parsed.key()
+	} else {
+		current, err := currentVersion(ctx, db, language)
+		if err != nil {
+			return nil, err
+		}
+		if current != nil {
+			key = current.VersionKey
+		}
+	}
+
+	var stages []bson.D
+	if key > 0 {
+		stages = append(stages, bson.D{{Key: "$match", Value: bson.D{{
+			Key: "versionkey", Value: bson.D{{Key: "$lte", Value: key}},
+		}}}})
+	}
+
+	return append(stages,
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "versionkey", Value: -1}}}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$cardcode"},
+			{Key: "card", Value: bson.D{{Key: "$first", Value: "$$ROOT"}}},
+		}}},
+		bson.D{{Key: "$replaceRoot", Value: bson.D{{Key: "newRoot", Value: "$card"}}}},
+	), nil
+}
+
+// FindCards finds cards by code as they were in the given version, or
+// in the current version of the language if version is empty.
+func (m *Mongo) FindCards(ctx context.Context, language string, version string, codes ...string) ([]*Card, error) {
+	inCards := make(bson.A, len(codes))
+	for i, c := range codes {
+		inCards[i] = c
+	}
+	pipeline := bson.A{
+		bson.D{{
+			Key: "$match", Value: bson.D{{
+				Key: "cardcode", Value: bson.D{{
+					Key:   "$in",
+					Value: inCards,
+				}},
+			}},
+		}},
+	}
+
+	versionStages, err := versionPipeline(ctx, m.db, language, version)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range versionStages {
+		pipeline = append(pipeline, i)
+	}
+	pipeline = append(pipeline, sortByCodeStage)
+
+	coll := m.db.Collection(cardCollection(language))
+	c, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var cards []*Card
+	err = c.All(ctx, &cards)
+	return cards, err
+}
+
+// FindSnapshot returns all the cards of a language as they were in the
+// given version: the cards of the most recent bundle of each set saved up to
+// that version.
+func (m *Mongo) FindSnapshot(ctx context.Context, language string, version string) ([]*Card, error) {
+	v, err := parseVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := currentVersion(ctx, m.db, language)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil || v.key() > current.VersionKey {
+		return nil, fmt.Errorf("%w: %s", ErrVersionNotFound, version)
+	}
 
-		var cards []*Card
-		err = c.All(ctx, &cards)
+	opts := options.Find().SetSort(bson.D{{Key: "versionkey", Value: -1}})
+	c, err := m.db.Collection(collectionBundles).Find(ctx, bson.D{
+		{Key: "locale", Value: language},
+		{Key: "versionkey", Value: bson.D{{Key: "$lte", Value: v.key()}}},
+	}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve bundles: %w", err)
+	}
+
+	var bundles []setBundleWrite
+	if err := c.All(ctx, &bundles); err != nil {
+		return nil, fmt.Errorf("failed to retrieve bundles: %w", err)
+	}
 
-		cards = lo.UniqBy(cards, func(c *Card) string {
-			return c.Name
+	seen := make(map[string]bool)
+	sets := bson.A{}
+	for _, b := range bundles {
+		if seen[b.Set] {
+			continue
+		}
+		seen[b.Set] = true
+		sets = append(sets, bson.D{
+			{Key: "bundle", Value: b.Set},
+			{Key: "versionkey", Value: b.VersionKey},
 		})
+	}
+	if len(sets) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrVersionNotFound, version)
+	}
 
-		return cards, err
+	pipeline := bson.A{
+		bson.D{{Key: "$match", Value: bson.D{{Key: "$or", Value: sets}}}},
 	}
+	pipeline = append(pipeline, sortByCodeStage)
+
+	c, err = m.db.Collection(cardCollection(language)).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve cards: %w", err)
+	}
+
+	var cards []*Card
+	err = c.All(ctx, &cards)
+	return cards, err
+}
+
+// SearchByName searches cards by name as they were in the given
+// version, or in the current version of the language if version is empty.
+func (m *Mongo) SearchByName(ctx context.Context, language string, version string, name string) ([]*Card, error) {
+	if len(strings.Split(name, " ")) > 1 {
+		name = fmt.Sprintf(`"%s"`, name)
+	}
+
+	versionStages, err := versionPipeline(ctx, m.db, language, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// $text can only be the first stage, so the documents whose name matches
+	// in any version are found first, and the cards are kept only if the
+	// document of the version searched is one of them.
+	coll := m.db.Collection(cardCollection(language))
+	c, err := coll.Find(ctx,
+		bson.D{{Key: "$text", Value: bson.D{{Key: "$search", Value: name}}}},
+		options.Find().SetProjection(bson.D{{Key: "_id", Value: 1}, {Key: "cardcode", Value: 1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve card: %w", err)
+	}
+	var matched []struct {
+		ID       any    `bson:"_id"`
+		CardCode string `bson:"cardcode"`
+	}
+	if err := c.All(ctx, &matched); err != nil {
+		return nil, fmt.Errorf("failed to retrieve card: %w", err)
+	}
+	if len(matched) == 0 {
+		return nil, nil
+	}
+
+	ids, codes := bson.A{}, bson.A{}
+	for _, doc := range matched {
+		ids = append(ids, doc.ID)
+		codes = append(codes, doc.CardCode)
+	}
+	pipeline := bson.A{
+		bson.D{{Key: "$match", Value: bson.D{{Key: "cardcode", Value: bson.D{{Key: "$in", Value: codes}}}}}},
+	}
+	for _, i := range versionStages {
+		pipeline = append(pipeline, i)
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$match", Value: bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}}}}},
+		sortByCodeStage,
+	)
+
+	c, err = coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve card: %w", err)
+	}
+
+	var cards []*Card
+	if err := c.All(ctx, &cards); err != nil {
+		return nil, err
+	}
+
+	cards = lo.UniqBy(cards, func(c *Card) string {
+		return c.Name
+	})
+	if len(cards) > 25 {
+		cards = cards[:25]
+	}
+	return cards, nil
 }
 
 func cardCollection(lang string) string {
 	return fmt.Sprintf("%s_%s", collectionCards, lang)
 }
 
-func customFieldsPipeline() []bson.D {
-	return []bson.D{
-		{{Key: "$lookup",
-			Value: bson.D{
-				{Key: "from", Value: "cards_en_us"},
-				{Key: "localField", Value: "cardcode"},
-				{Key: "foreignField", Value: "cardcode"},
-				{Key: "as", Value: "en_us"},
-				{Key: "pipeline",
-					Value: bson.A{
-						bson.D{
-							{Key: "$project",
-								Value: bson.D{
-									{Key: "type", Value: 1},
-									{Key: "supertype", Value: 1},
-								},
-							},
-						},
-					},
-				},
-			},
-		}},
-		{{Key: "$unwind", Value: "$en_us"}},
-		{
-			{Key: "$addFields",
-				Value: bson.D{
-					{Key: "supertyperef", Value: "$en_us.supertype"},
-					{Key: "typeref", Value: "$en_us.type"},
-				},
-			},
+func globalsCollection(lang string) string {
+	return fmt.Sprintf("%s_%s", collectionGlobals, lang)
+}
+
+// sortByCodeStage sorts the cards found like the other repositories do.
+var sortByCodeStage = bson.D{{Key: "$sort", Value: bson.D{{Key: "cardcode", Value: 1}}}}
+
+// GuildConfig returns the settings of a guild, with empty settings if it
+// never changed them.
+func (m *Mongo) GuildConfig(ctx context.Context, guild string) (*GuildConfig, error) {
+	config := &GuildConfig{Guild: guild, SchemaVersion: GuildConfigSchema}
+	err := m.db.Collection(collectionConfig).FindOne(ctx, bson.D{{Key: "guild", Value: guild}}).Decode(config)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// UpdateGuildConfig changes the settings of a guild with update and saves
+// them, calling update again if the settings were saved by someone else
+// meanwhile.
+func (m *Mongo) UpdateGuildConfig(ctx context.Context, guild string, update func(*GuildConfig)) error {
+	return updateRevision(ctx, m.db.Collection(collectionConfig),
+		bson.D{{Key: "guild", Value: guild}},
+		GuildConfig{Guild: guild, SchemaVersion: GuildConfigSchema},
+		func(config *GuildConfig) {
+			update(config)
+			config.Guild, config.SchemaVersion = guild, GuildConfigSchema
 		},
-		{{Key: "$project", Value: bson.D{{Key: "result", Value: 0}}}},
-		{{Key: "$sort", Value: bson.D{{Key: "cardcode", Value: 1}}}},
+	)
+}
+
+// maxRevisionAttempts is the number of times updateRevision reads a document
+// again after losing to concurrent updates.
+const maxRevisionAttempts = 10
+
+// revisioned is a stored document with the number of times it was updated,
+// so updates can tell whether the document changed since it was read.
+type revisioned[T any] struct {
+	Doc      T   `bson:",inline"`
+	Revision int `bson:"revision"`
+}
+
+// updateRevision changes the document matched by filter, or empty if there is
+// none, with update and saves it if no other update saved it since it was
+// read. Otherwise it reads the document and calls update again. The first
+// update of a document inserts it, losing to concurrent ones through the
+// unique index of the collection.
+func updateRevision[T any](ctx context.Context, coll *mongo.Collection, filter bson.D, empty T, update func(*T)) error {
+	for attempt := 0; attempt < maxRevisionAttempts; attempt++ {
+		doc := revisioned[T]{Doc: empty}
+		err := coll.FindOne(ctx, filter).Decode(&doc)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			update(&doc.Doc)
+			doc.Revision = 1
+			_, err := coll.InsertOne(ctx, doc)
+			if mongo.IsDuplicateKeyError(err) {
+				continue
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		// Documents saved before revisions were stored have none.
+		read := any(doc.Revision)
+		if doc.Revision == 0 {
+			read = bson.D{{Key: "$in", Value: bson.A{nil, 0}}}
+		}
+		update(&doc.Doc)
+		doc.Revision++
+
+		res, err := coll.ReplaceOne(ctx, append(filter, bson.E{Key: "revision", Value: read}), doc)
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount > 0 {
+			return nil
+		}
 	}
+	return fmt.Errorf("failed to update %s after %d concurrent updates", coll.Name(), maxRevisionAttempts)
 }
 
-func SaveLang(cli *mongo.Client) func(ctx context.Context, guild string, lang string) error {
-	return func(ctx context.Context, guild string, lang string) error {
-		coll := cli.Database(database).Collection("config")
-		_, err := coll.UpdateOne(ctx,
-			bson.D{{
-				Key: "guild", Value: guild,
-			}},
-			bson.D{
-				{Key: "$set", Value: bson.D{
-					{Key: "language", Value: lang},
-				}},
-			}, options.Update().SetUpsert(true),
-		)
+// userPrefsWrite is the stored preferences document of a user.
+type userPrefsWrite struct {
+	User        string `bson:"user"`
+	Preferences `bson:",inline"`
+}
 
-		return err
+// UserPrefs returns the preferences of a user, with empty preferences if
+// they never changed them.
+func (m *Mongo) UserPrefs(ctx context.Context, user string) (*Preferences, error) {
+	var prefs userPrefsWrite
+	err := m.db.Collection(collectionUsers).FindOne(ctx, bson.D{{Key: "user", Value: user}}).Decode(&prefs)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return &Preferences{}, nil
+	}
+	if err != nil {
+		return nil, err
 	}
+	return &prefs.Preferences, nil
 }
 
-func SaveURLTemplate(cli *mongo.Client) func(ctx context.Context, guild string, template string, label string) error {
-	return func(ctx context.Context, guild, template, label string) error {
-		coll := cli.Database(database).Collection("config")
+// UpdateUserPrefs changes the preferences of a user with update and saves
+// them, calling update again if the preferences were saved by someone else
+// meanwhile.
+func (m *Mongo) UpdateUserPrefs(ctx context.Context, user string, update func(*Preferences)) error {
+	return updateRevision(ctx, m.db.Collection(collectionUsers),
+		bson.D{{Key: "user", Value: user}},
+		userPrefsWrite{User: user},
+		func(prefs *userPrefsWrite) {
+			update(&prefs.Preferences)
+			prefs.User = user
+		},
+	)
+}
 
-		_, err := coll.UpdateOne(ctx,
-			bson.D{{
-				Key: "guild", Value: guild,
-			}},
-			bson.D{
-				{Key: "$set", Value: bson.D{
-					{Key: "template", Value: template},
-					{Key: "label", Value: label},
-				}},
-			}, options.Update().SetUpsert(true),
-		)
-		return err
+// DeckBuild returns the deck a user is building, with no cards if they never
+// saved one.
+func (m *Mongo) DeckBuild(ctx context.Context, user string) (*DeckBuild, error) {
+	build := &DeckBuild{User: user}
+	err := m.db.Collection(collectionDeckBuilds).FindOne(ctx, bson.D{{Key: "user", Value: user}}).Decode(build)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return build, nil
+	}
+	if err != nil {
+		return nil, err
 	}
+	return build, nil
 }
 
-func GetLang(cli *mongo.Client) func(ctx context.Context, guild string) (string, error) {
-	return func(ctx context.Context, guild string) (string, error) {
-		coll := cli.Database(database).Collection("config")
-		r := coll.FindOne(ctx, bson.D{{
-			Key: "guild", Value: guild,
-		}})
+// UpdateDeckBuild changes the deck a user is building with update and saves
+// it, calling update again if the deck was saved by someone else meanwhile.
+func (m *Mongo) UpdateDeckBuild(ctx context.Context, user string, update func(*DeckBuild)) error {
+	return updateRevision(ctx, m.db.Collection(collectionDeckBuilds),
+		bson.D{{Key: "user", Value: user}},
+		DeckBuild{User: user},
+		func(build *DeckBuild) {
+			update(build)
+			build.User = user
+		},
+	)
+}
 
-		config := make(map[string]string)
-		if r.Err() != nil {
-			return "", r.Err()
-		}
+// mongoMigration upgrades the guild configurations to a schema version.
+type mongoMigration struct {
+	version int
+	migrate func(ctx context.Context, coll *mongo.Collection) error
+}
+
+var mongoMigrations = []mongoMigration{
+	{version: 1, migrate: mergeGuildConfigs},
+}
 
-		err := r.Decode(&config)
+// Migrate upgrades the guild configurations written by previous versions of
+// the bot, creates the indexes on guild and user and sets the refs and bundles
+// of cards saved before they were stored on each card.
+func (m *Mongo) Migrate(ctx context.Context) error {
+	coll := m.db.Collection(collectionConfig)
+	for _, migration := range mongoMigrations {
+		outdated := bson.D{{Key: "schemaversion", Value: bson.D{{Key: "$not", Value: bson.D{{Key: "$gte", Value: migration.version}}}}}}
+		n, err := coll.CountDocuments(ctx, outdated, options.Count().SetLimit(1))
 		if err != nil {
-			return "", err
+			return err
 		}
+		if n == 0 {
+			continue
+		}
+
+		if err := migration.migrate(ctx, coll); err != nil {
+			return fmt.Errorf("failed to migrate guild configurations to version %d: %w", migration.version, err)
+		}
+		if _, err := coll.UpdateMany(ctx, outdated, bson.D{{Key: "$set", Value: bson.D{{Key: "schemaversion", Value: migration.version}}}}); err != nil {
+			return err
+		}
+	}
+
+	_, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "guild", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create guild index: %w", err)
+	}
+	_, err = m.db.Collection(collectionUsers).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create user index: %w", err)
+	}
+	_, err = m.db.Collection(collectionDeckBuilds).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create deck build index: %w", err)
+	}
 
-		return config["language"], nil
+	if err := m.BackfillRefs(ctx); err != nil {
+		return err
 	}
+	return m.BackfillBundles(ctx)
 }
 
-func GetTemplate(cli *mongo.Client) func(ctx context.Context, guild string) (string, string, error) {
-	return func(ctx context.Context, guild string) (string, string, error) {
-		coll := cli.Database(database).Collection("config")
-		r := coll.FindOne(ctx, bson.D{{
-			Key: "guild", Value: guild,
-		}})
+// mergeGuildConfigs merges the documents saved for the same guild, which
+// concurrent upserts could create before the index on guild was unique.
+// Settings of the most recent documents win.
+func mergeGuildConfigs(ctx context.Context, coll *mongo.Collection) error {
+	c, err := coll.Aggregate(ctx, bson.A{
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$guild"},
+			{Key: "docs", Value: bson.D{{Key: "$push", Value: "$$ROOT"}}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		bson.D{{Key: "$match", Value: bson.D{{Key: "count", Value: bson.D{{Key: "$gt", Value: 1}}}}}},
+	})
+	if err != nil {
+		return err
+	}
 
-		config := make(map[string]string)
-		if r.Err() != nil {
-			return "", "", r.Err()
-		}
+	var duplicates []struct {
+		Docs []bson.M `bson:"docs"`
+	}
+	if err := c.All(ctx, &duplicates); err != nil {
+		return err
+	}
 
-		err := r.Decode(&config)
-		if err != nil {
-			return "", "", err
+	for _, d := range duplicates {
+		merged := bson.M{}
+		for _, doc := range d.Docs {
+			for k, v := range doc {
+				if v != "" {
+					merged[k] = v
+				}
+			}
 		}
 
-		return config["template"], config["label"], nil
+		keep := d.Docs[len(d.Docs)-1]["_id"]
+		ids := lo.Map(d.Docs[:len(d.Docs)-1], func(doc bson.M, _ int) any {
+			return doc["_id"]
+		})
+		if _, err := coll.DeleteMany(ctx, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}}}); err != nil {
+			return err
+		}
+		if _, err := coll.ReplaceOne(ctx, bson.D{{Key: "_id", Value: keep}}, merged); err != nil {
+			return err
+		}
 	}
+	return nil
 }
@@ -0,0 +1,30 @@
+package repository
+
+// refsLocale is the locale the language independent fields of the cards are
+// taken from. Data Dragon only has them translated, so the English values
+// are used as refs.
+const refsLocale = "en_us"
+
+// cardRefs are the language independent fields of a card that Data Dragon
+// does not have. They are set when cards are saved, from the most recent
+// en_us card with the same code.
+type cardRefs struct {
+	TypeRef      string `bson:"typeref"`
+	SupertypeRef string `bson:"supertyperef"`
+}
+
+func refsOf(c *Card) cardRefs {
+	return cardRefs{TypeRef: c.Type, SupertypeRef: c.Supertype}
+}
+
+func (r cardRefs) apply(c *Card) {
+	c.TypeRef, c.SupertypeRef = r.TypeRef, r.SupertypeRef
+}
+
+func cardCodes(cards []*Card) []string {
+	codes := make([]string, len(cards))
+	for i, c := range cards {
+		codes[i] = c.CardCode
+	}
+	return codes
+}
@@ -0,0 +1,88 @@
+package repository
+
+import "context"
+
+// CardRepository finds the cards shown by the bot. Versions are game patches
+// like "4.10.0": cards are returned as they were in the greatest stored
+// version not above it, and an empty version means the current one.
+type CardRepository interface {
+	// FindCards returns the cards with the given codes. Cards not found are
+	// left out.
+	FindCards(ctx context.Context, language string, version string, codes ...string) ([]*Card, error)
+	// SearchByName returns the cards whose name matches name.
+	SearchByName(ctx context.Context, language string, version string, name string) ([]*Card, error)
+	// FindSnapshot returns every card of a version. It returns
+	// ErrVersionNotFound if the version is not stored.
+	FindSnapshot(ctx context.Context, language string, version string) ([]*Card, error)
+}
+
+// GuildConfigRepository stores the settings of each guild.
+type GuildConfigRepository interface {
+	// GuildConfig returns the settings of a guild. Settings never saved are
+	// empty.
+	GuildConfig(ctx context.Context, guild string) (*GuildConfig, error)
+	// UpdateGuildConfig changes the settings of a guild with update and saves
+	// them.
+	UpdateGuildConfig(ctx context.Context, guild string, update func(*GuildConfig)) error
+}
+
+// UserPrefsRepository stores the preferences of each user, which apply in
+// every guild.
+type UserPrefsRepository interface {
+	// UserPrefs returns the preferences of a user. Preferences never saved
+	// are empty.
+	UserPrefs(ctx context.Context, user string) (*Preferences, error)
+	// UpdateUserPrefs changes the preferences of a user with update and
+	// saves them.
+	UpdateUserPrefs(ctx context.Context, user string, update func(*Preferences)) error
+}
+
+// DeckBuildRepository stores the deck each user is building, so they can
+// leave and come back to it.
+type DeckBuildRepository interface {
+	// DeckBuild returns the deck a user is building. Decks never saved are
+	// empty.
+	DeckBuild(ctx context.Context, user string) (*DeckBuild, error)
+	// UpdateDeckBuild changes the deck a user is building with update and
+	// saves it.
+	UpdateDeckBuild(ctx context.Context, user string, update func(*DeckBuild)) error
+}
+
+// BundleRepository stores the bundles downloaded from Data Dragon.
+type BundleRepository interface {
+	// SaveBundle saves the cards of a bundle. Bundles not more recent than
+	// the stored ones are skipped unless force is set.
+	SaveBundle(ctx context.Context, bundle *SetBundle, force bool) error
+	// SaveGlobals saves the core bundle metadata of a locale, following the
+	// same rules as SaveBundle.
+	SaveGlobals(ctx context.Context, globals *Globals, force bool) error
+	// FindBundle returns the latest stored bundle of a set, or nil if it was
+	// never saved.
+	FindBundle(ctx context.Context, set string, locale string) (*SetBundle, error)
+	// FindGlobals returns the stored core bundle metadata of a locale, or nil
+	// if it was never saved.
+	FindGlobals(ctx context.Context, locale string) (*Globals, error)
+	// Quarantine saves the cards left out of their bundles.
+	Quarantine(ctx context.Context, anomalies []Anomaly) error
+	// StoredVersion returns the greatest version stored.
+	StoredVersion(ctx context.Context) (string, error)
+	// CurrentVersion returns the version cards of a locale are shown at by
+	// default.
+	CurrentVersion(ctx context.Context, locale string) (string, error)
+}
+
+// Migrator is implemented by the repositories that upgrade the documents
+// written by previous versions of the bot. Migrate runs at startup and does
+// nothing when the documents are up to date.
+type Migrator interface {
+	Migrate(ctx context.Context) error
+}
+
+// Repository is a storage backend for the bot.
+type Repository interface {
+	CardRepository
+	GuildConfigRepository
+	UserPrefsRepository
+	DeckBuildRepository
+	BundleRepository
+}
@@ -0,0 +1,553 @@
+// Package repositorytest holds the specs every repository backend must pass.
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dneto/sai-scout/internal/repository"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var lastModified = time.Date(2023, time.September, 1, 12, 0, 0, 0, time.UTC)
+
+// Contract declares the repository specs, running each one against a new
+// empty repository returned by newRepository. It must be called from a
+// ginkgo container node.
+func Contract(newRepository func() repository.Repository) {
+	var (
+		ctx  = context.Background()
+		repo repository.Repository
+	)
+
+	BeforeEach(func() {
+		repo = newRepository()
+	})
+
+	save := func(b *repository.SetBundle) {
+		GinkgoHelper()
+		Expect(repo.SaveBundle(ctx, b, false)).To(Succeed())
+	}
+
+	Describe("CardRepository", func() {
+		BeforeEach(func() {
+			save(bundle("set1", "en_us", "4.9.0", lastModified,
+				card("01DE001", "Vanguard Defender", "Unit", 2),
+				card("01DE002", "Vanguard Sergeant", "Unit", 3),
+			))
+			save(bundle("set1", "pt_br", "4.9.0", lastModified,
+				card("01DE001", "Defensora da Vanguarda", "Unidade", 2),
+				card("01DE002", "Sargento da Vanguarda", "Unidade", 3),
+			))
+			save(bundle("set1", "en_us", "4.10.0", lastModified,
+				card("01DE001", "Vanguard Defender", "Unit", 1),
+			))
+		})
+
+		Context("FindCards", func() {
+			It("finds cards in the current version", func() {
+				cards, err := repo.FindCards(ctx, "en_us", "", "01DE001")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cards).To(HaveLen(1))
+				Expect(cards[0].Cost).To(Equal(1))
+				Expect(cards[0].Version).To(Equal("4.10.0"))
+			})
+
+			It("finds cards as of a previous version", func() {
+				cards, err := repo.FindCards(ctx, "en_us", "4.9.0", "01DE001")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cards).To(HaveLen(1))
+				Expect(cards[0].Cost).To(Equal(2))
+				Expect(cards[0].Version).To(Equal("4.9.0"))
+			})
+
+			It("uses the greatest version up to the given one", func() {
+				cards, err := repo.FindCards(ctx, "en_us", "4.9.5", "01DE001")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cards).To(HaveLen(1))
+				Expect(cards[0].Version).To(Equal("4.9.0"))
+			})
+
+			It("sorts cards by code and leaves out unknown codes", func() {
+				cards, err := repo.FindCards(ctx, "en_us", "", "01DE002", "unknown", "01DE001")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(codes(cards)).To(Equal([]string{"01DE001", "01DE002"}))
+			})
+
+			It("returns no cards before the first version", func() {
+				cards, err := repo.FindCards(ctx, "en_us", "4.8.0", "01DE001")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cards).To(BeEmpty())
+			})
+
+			It("sets the type refs from the en_us cards", func() {
+				cards, err := repo.FindCards(ctx, "pt_br", "", "01DE001")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cards).To(HaveLen(1))
+				Expect(cards[0].Type).To(Equal("Unidade"))
+				Expect(cards[0].TypeRef).To(Equal("Unit"))
+				Expect(cards[0].SupertypeRef).To(BeEmpty())
+			})
+
+			It("sets the type refs of cards saved before the en_us cards", func() {
+				save(bundle("set2", "pt_br", "4.10.0", lastModified,
+					card("02NX001", "Lâmina Voraz", "Feitiço", 1),
+				))
+				save(bundle("set2", "en_us", "4.10.0", lastModified,
+					card("02NX001", "Ravenous Blade", "Spell", 1),
+				))
+
+				cards, err := repo.FindCards(ctx, "pt_br", "", "02NX001")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cards).To(HaveLen(1))
+				Expect(cards[0].TypeRef).To(Equal("Spell"))
+			})
+
+			It("updates the type refs of every version when en_us cards change", func() {
+				changed := card("01DE001", "Vanguard Defender", "Spell", 1)
+				changed.Supertype = "Champion"
+				save(bundle("set1", "en_us", "4.11.0", lastModified, changed))
+
+				cards, err := repo.FindCards(ctx, "pt_br", "4.9.0", "01DE001")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cards).To(HaveLen(1))
+				Expect(cards[0].TypeRef).To(Equal("Spell"))
+				Expect(cards[0].SupertypeRef).To(Equal("Champion"))
+			})
+
+			It("fails on invalid versions", func() {
+				_, err := repo.FindCards(ctx, "en_us", "latest", "01DE001")
+				Expect(err).To(MatchError(repository.ErrInvalidVersion))
+			})
+		})
+
+		Context("SearchByName", func() {
+			It("finds cards by a word of the name", func() {
+				cards, err := repo.SearchByName(ctx, "en_us", "", "Sergeant")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(codes(cards)).To(Equal([]string{"01DE002"}))
+			})
+
+			It("finds cards by the full name", func() {
+				cards, err := repo.SearchByName(ctx, "pt_br", "", "Defensora da Vanguarda")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(codes(cards)).To(Equal([]string{"01DE001"}))
+				Expect(cards[0].TypeRef).To(Equal("Unit"))
+			})
+
+			It("finds cards as of a previous version", func() {
+				cards, err := repo.SearchByName(ctx, "en_us", "4.9.0", "Defender")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cards).To(HaveLen(1))
+				Expect(cards[0].Cost).To(Equal(2))
+			})
+
+			It("returns no cards for unknown names", func() {
+				cards, err := repo.SearchByName(ctx, "en_us", "", "Teemo")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cards).To(BeEmpty())
+			})
+
+			It("matches the names of the version searched", func() {
+				save(bundle("set1", "en_us", "4.11.0", lastModified,
+					card("01DE002", "Vanguard Captain", "Unit", 3),
+				))
+
+				cards, err := repo.SearchByName(ctx, "en_us", "", "Sergeant")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cards).To(BeEmpty())
+
+				cards, err = repo.SearchByName(ctx, "en_us", "", "Captain")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(codes(cards)).To(Equal([]string{"01DE002"}))
+
+				cards, err = repo.SearchByName(ctx, "en_us", "4.10.0", "Sergeant")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(codes(cards)).To(Equal([]string{"01DE002"}))
+			})
+
+			It("returns up to 25 cards with distinct names", func() {
+				var poros []*repository.Card
+				for i := 0; i < 40; i++ {
+					name := "Poro"
+					if i >= 10 {
+						name = fmt.Sprintf("Poro %d", i)
+					}
+					poros = append(poros, card(fmt.Sprintf("01PR%03d", i), name, "Unit", 1))
+				}
+				save(bundle("set1", "en_us", "4.11.0", lastModified, poros...))
+
+				cards, err := repo.SearchByName(ctx, "en_us", "", "Poro")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cards).To(HaveLen(25))
+				names := make(map[string]bool)
+				for _, c := range cards {
+					names[c.Name] = true
+				}
+				Expect(names).To(HaveLen(25))
+			})
+		})
+
+		Context("FindSnapshot", func() {
+			It("returns the cards of the latest bundle of each set", func() {
+				cards, err := repo.FindSnapshot(ctx, "en_us", "4.10.0")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(codes(cards)).To(Equal([]string{"01DE001"}))
+				Expect(cards[0].TypeRef).To(Equal("Unit"))
+			})
+
+			It("returns the cards of previous versions", func() {
+				cards, err := repo.FindSnapshot(ctx, "en_us", "4.9.0")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(codes(cards)).To(Equal([]string{"01DE001", "01DE002"}))
+			})
+
+			It("fails on versions not stored", func() {
+				_, err := repo.FindSnapshot(ctx, "en_us", "4.11.0")
+				Expect(err).To(MatchError(repository.ErrVersionNotFound))
+
+				_, err = repo.FindSnapshot(ctx, "en_us", "4.8.0")
+				Expect(err).To(MatchError(repository.ErrVersionNotFound))
+			})
+
+			It("fails on invalid versions", func() {
+				_, err := repo.FindSnapshot(ctx, "en_us", "latest")
+				Expect(err).To(MatchError(repository.ErrInvalidVersion))
+			})
+		})
+	})
+
+	Describe("BundleRepository", func() {
+		Context("SaveBundle", func() {
+			It("skips bundles that are not more recent", func() {
+				save(bundle("set1", "en_us", "4.9.0", lastModified, card("01DE001", "Vanguard Defender", "Unit", 2)))
+				save(bundle("set1", "en_us", "4.9.0", lastModified.Add(-time.Hour), card("01DE001", "Vanguard Defender", "Unit", 3)))
+
+				cards, err := repo.FindCards(ctx, "en_us", "", "01DE001")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cards[0].Cost).To(Equal(2))
+			})
+
+			It("replaces cards of more recent bundles", func() {
+				save(bundle("set1", "en_us", "4.9.0", lastModified, card("01DE001", "Vanguard Defender", "Unit", 2)))
+				save(bundle("set1", "en_us", "4.9.0", lastModified.Add(time.Hour), card("01DE001", "Vanguard Defender", "Unit", 3)))
+
+				cards, err := repo.FindCards(ctx, "en_us", "", "01DE001")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cards[0].Cost).To(Equal(3))
+			})
+
+			It("replaces cards when forced", func() {
+				save(bundle("set1", "en_us", "4.9.0", lastModified, card("01DE001", "Vanguard Defender", "Unit", 2)))
+				Expect(repo.SaveBundle(ctx, bundle("set1", "en_us", "4.9.0", lastModified, card("01DE001", "Vanguard Defender", "Unit", 3)), true)).To(Succeed())
+
+				cards, err := repo.FindCards(ctx, "en_us", "", "01DE001")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cards[0].Cost).To(Equal(3))
+			})
+
+			It("fails on invalid versions", func() {
+				err := repo.SaveBundle(ctx, bundle("set1", "en_us", "latest", lastModified), false)
+				Expect(err).To(MatchError(repository.ErrInvalidVersion))
+			})
+		})
+
+		Context("FindBundle", func() {
+			It("returns the most recent bundle", func() {
+				save(bundle("set1", "en_us", "4.10.0", lastModified))
+				save(bundle("set1", "en_us", "4.9.0", lastModified.Add(time.Hour)))
+
+				b, err := repo.FindBundle(ctx, "set1", "en_us")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(b.Version).To(Equal("4.10.0"))
+				Expect(b.LastModified).To(BeTemporally("==", lastModified))
+			})
+
+			It("returns nil for bundles never saved", func() {
+				b, err := repo.FindBundle(ctx, "set1", "en_us")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(b).To(BeNil())
+			})
+		})
+
+		Context("Globals", func() {
+			It("saves and finds globals", func() {
+				Expect(repo.SaveGlobals(ctx, globals("4.9.0", lastModified), false)).To(Succeed())
+
+				g, err := repo.FindGlobals(ctx, "en_us")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(g.Version).To(Equal("4.9.0"))
+				Expect(g.Keywords).To(HaveLen(1))
+			})
+
+			It("keeps the globals of more recent versions", func() {
+				Expect(repo.SaveGlobals(ctx, globals("4.10.0", lastModified), false)).To(Succeed())
+				Expect(repo.SaveGlobals(ctx, globals("4.9.0", lastModified.Add(time.Hour)), false)).To(Succeed())
+
+				g, err := repo.FindGlobals(ctx, "en_us")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(g.Version).To(Equal("4.10.0"))
+
+				Expect(repo.SaveGlobals(ctx, globals("4.9.0", lastModified), true)).To(Succeed())
+				g, err = repo.FindGlobals(ctx, "en_us")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(g.Version).To(Equal("4.9.0"))
+			})
+
+			It("returns nil for globals never saved", func() {
+				g, err := repo.FindGlobals(ctx, "en_us")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(g).To(BeNil())
+			})
+		})
+
+		Context("versions", func() {
+			It("returns empty versions when nothing was saved", func() {
+				Expect(repo.StoredVersion(ctx)).To(BeEmpty())
+				Expect(repo.CurrentVersion(ctx, "en_us")).To(BeEmpty())
+			})
+
+			It("never moves the current version back", func() {
+				save(bundle("set1", "en_us", "4.10.0", lastModified))
+				save(bundle("set1", "en_us", "4.9.0", lastModified))
+				Expect(repo.SaveGlobals(ctx, globals("4.9.0", lastModified), true)).To(Succeed())
+
+				Expect(repo.CurrentVersion(ctx, "en_us")).To(Equal("4.10.0"))
+				Expect(repo.CurrentVersion(ctx, "pt_br")).To(BeEmpty())
+				Expect(repo.StoredVersion(ctx)).To(Equal("4.10.0"))
+			})
+		})
+
+		Context("Quarantine", func() {
+			It("saves anomalies", func() {
+				c := card("01DE001", "", "Unit", 2)
+				Expect(repo.Quarantine(ctx, []repository.Anomaly{{
+					CardCode: c.CardCode,
+					Set:      "set1",
+					Locale:   "en_us",
+					Version:  "4.9.0",
+					Reasons:  repository.ValidateCard(c),
+					Card:     c,
+				}})).To(Succeed())
+				Expect(repo.Quarantine(ctx, nil)).To(Succeed())
+			})
+		})
+	})
+
+	Describe("GuildConfigRepository", func() {
+		It("returns empty settings for unknown guilds", func() {
+			config, err := repo.GuildConfig(ctx, "guild")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(Equal(&repository.GuildConfig{Guild: "guild", SchemaVersion: repository.GuildConfigSchema}))
+		})
+
+		It("saves settings by guild", func() {
+			Expect(repo.UpdateGuildConfig(ctx, "guild", func(c *repository.GuildConfig) {
+				c.Language = "pt_br"
+			})).To(Succeed())
+			Expect(repo.UpdateGuildConfig(ctx, "guild", func(c *repository.GuildConfig) {
+				c.URLTemplate, c.URLLabel = "https://example.com/{{code}}", "Example"
+			})).To(Succeed())
+			Expect(repo.UpdateGuildConfig(ctx, "guild", func(c *repository.GuildConfig) {
+				Expect(c.Language).To(Equal("pt_br"))
+				c.Language = "es_es"
+			})).To(Succeed())
+
+			config, err := repo.GuildConfig(ctx, "guild")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(Equal(&repository.GuildConfig{
+				Guild:         "guild",
+				SchemaVersion: repository.GuildConfigSchema,
+				Preferences: repository.Preferences{
+					Language:    "es_es",
+					URLTemplate: "https://example.com/{{code}}",
+					URLLabel:    "Example",
+				},
+			}))
+
+			other, err := repo.GuildConfig(ctx, "other")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(other.Language).To(BeEmpty())
+		})
+
+		It("saves the preferences of channels", func() {
+			Expect(repo.UpdateGuildConfig(ctx, "guild", func(c *repository.GuildConfig) {
+				c.Language = "pt_br"
+				c.Channels = map[string]repository.Preferences{"channel": {Language: "es_es"}}
+			})).To(Succeed())
+
+			config, err := repo.GuildConfig(ctx, "guild")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.Language).To(Equal("pt_br"))
+			Expect(config.Channels).To(Equal(map[string]repository.Preferences{"channel": {Language: "es_es"}}))
+
+			config.Channels["other"] = repository.Preferences{Language: "ja_jp"}
+			config, err = repo.GuildConfig(ctx, "guild")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.Channels).To(HaveLen(1))
+		})
+
+		It("saves the aliases of cards", func() {
+			Expect(repo.UpdateGuildConfig(ctx, "guild", func(c *repository.GuildConfig) {
+				c.Aliases = append(c.Aliases, repository.Alias{Term: "TF", CardCode: "01PZ040"})
+			})).To(Succeed())
+
+			config, err := repo.GuildConfig(ctx, "guild")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.Aliases).To(Equal([]repository.Alias{{Term: "TF", CardCode: "01PZ040"}}))
+
+			config.Aliases[0].CardCode = "01IO001"
+			config, err = repo.GuildConfig(ctx, "guild")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.Aliases[0].CardCode).To(Equal("01PZ040"))
+		})
+
+		It("keeps every concurrent update", func() {
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					Expect(repo.UpdateGuildConfig(ctx, "guild", func(c *repository.GuildConfig) {
+						c.Aliases = append(c.Aliases, repository.Alias{Term: fmt.Sprint(i), CardCode: "01PZ040"})
+					})).To(Succeed())
+				}(i)
+			}
+			wg.Wait()
+
+			config, err := repo.GuildConfig(ctx, "guild")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.Aliases).To(HaveLen(10))
+		})
+	})
+
+	Describe("UserPrefsRepository", func() {
+		It("returns empty preferences for unknown users", func() {
+			Expect(repo.UserPrefs(ctx, "user")).To(Equal(&repository.Preferences{}))
+		})
+
+		It("saves preferences by user", func() {
+			Expect(repo.UpdateUserPrefs(ctx, "user", func(p *repository.Preferences) {
+				p.Language = "pt_br"
+			})).To(Succeed())
+			Expect(repo.UpdateUserPrefs(ctx, "user", func(p *repository.Preferences) {
+				p.URLTemplate, p.URLLabel = "https://example.com/{{code}}", "Example"
+			})).To(Succeed())
+
+			Expect(repo.UserPrefs(ctx, "user")).To(Equal(&repository.Preferences{
+				Language:    "pt_br",
+				URLTemplate: "https://example.com/{{code}}",
+				URLLabel:    "Example",
+			}))
+			Expect(repo.UserPrefs(ctx, "other")).To(Equal(&repository.Preferences{}))
+		})
+
+		It("keeps every concurrent update", func() {
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					Expect(repo.UpdateUserPrefs(ctx, "user", func(p *repository.Preferences) {
+						p.URLLabel += "x"
+					})).To(Succeed())
+				}()
+			}
+			wg.Wait()
+
+			Expect(repo.UserPrefs(ctx, "user")).To(HaveField("URLLabel", "xxxxxxxxxx"))
+		})
+	})
+
+	Describe("DeckBuildRepository", func() {
+		It("returns no cards for unknown users", func() {
+			Expect(repo.DeckBuild(ctx, "user")).To(Equal(&repository.DeckBuild{User: "user"}))
+		})
+
+		It("saves decks by user", func() {
+			Expect(repo.UpdateDeckBuild(ctx, "user", func(b *repository.DeckBuild) {
+				b.Cards = append(b.Cards, repository.DeckBuildCard{CardCode: "01PZ040", Count: 3})
+			})).To(Succeed())
+			Expect(repo.UpdateDeckBuild(ctx, "user", func(b *repository.DeckBuild) {
+				b.Cards = append(b.Cards, repository.DeckBuildCard{CardCode: "01IO001", Count: 1})
+			})).To(Succeed())
+
+			build, err := repo.DeckBuild(ctx, "user")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(build).To(Equal(&repository.DeckBuild{User: "user", Cards: []repository.DeckBuildCard{
+				{CardCode: "01PZ040", Count: 3},
+				{CardCode: "01IO001", Count: 1},
+			}}))
+			Expect(repo.DeckBuild(ctx, "other")).To(Equal(&repository.DeckBuild{User: "other"}))
+
+			build.Cards[0].Count = 1
+			Expect(repo.DeckBuild(ctx, "user")).To(HaveField("Cards", HaveExactElements(
+				repository.DeckBuildCard{CardCode: "01PZ040", Count: 3},
+				repository.DeckBuildCard{CardCode: "01IO001", Count: 1},
+			)))
+		})
+
+		It("keeps every concurrent update", func() {
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					Expect(repo.UpdateDeckBuild(ctx, "user", func(b *repository.DeckBuild) {
+						b.Cards = append(b.Cards, repository.DeckBuildCard{CardCode: fmt.Sprintf("01PZ%03d", i), Count: 1})
+					})).To(Succeed())
+				}(i)
+			}
+			wg.Wait()
+
+			Expect(repo.DeckBuild(ctx, "user")).To(HaveField("Cards", HaveLen(10)))
+		})
+	})
+}
+
+func bundle(set string, locale string, version string, lastModified time.Time, cards ...*repository.Card) *repository.SetBundle {
+	return &repository.SetBundle{Set: set, Locale: locale, Version: version, LastModified: lastModified, Cards: cards}
+}
+
+func card(code string, name string, typ string, cost int) *repository.Card {
+	c := &repository.Card{
+		CardCode:   code,
+		Name:       name,
+		Type:       typ,
+		Cost:       cost,
+		Set:        "Set1",
+		RegionRefs: []string{"Demacia"},
+		RarityRef:  "Common",
+	}
+	c.Assets = append(c.Assets, struct {
+		GameAbsolutePath string `json:"gameAbsolutePath"`
+		FullAbsolutePath string `json:"fullAbsolutePath"`
+	}{
+		GameAbsolutePath: "https://dd.b.pvp.net/latest/set1/en_us/img/cards/" + code + ".png",
+		FullAbsolutePath: "https://dd.b.pvp.net/latest/set1/en_us/img/cards/" + code + "-full.png",
+	})
+	return c
+}
+
+func globals(version string, lastModified time.Time) *repository.Globals {
+	return &repository.Globals{
+		Locale:       "en_us",
+		Version:      version,
+		LastModified: lastModified,
+		Keywords:     []repository.Keyword{{Name: "Elusive", NameRef: "Elusive"}},
+	}
+}
+
+func codes(cards []*repository.Card) []string {
+	codes := make([]string, len(cards))
+	for i, c := range cards {
+		codes[i] = c.CardCode
+	}
+	return codes
+}
@@ -0,0 +1,125 @@
+package repository_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dneto/sai-scout/internal/repository"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestRepository(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Repository Suite")
+}
+
+var lastModified = time.Date(2023, time.September, 1, 12, 0, 0, 0, time.UTC)
+
+// fakeCDN is a local stand-in for Data Dragon serving the given versions.
+type fakeCDN struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	versions map[string]bool
+	requests []string
+
+	// Sets are the set refs listed in the globals bundle. Published lists
+	// the set bundles available and Broken the ones answering with errors.
+	// Missing lists the bundles of sets not available for a locale, like
+	// "set1-pt_br".
+	Sets      []string
+	Published map[string]bool
+	Broken    map[string]bool
+	Missing   map[string]bool
+	// Flaky holds how many times each set answers with transient errors
+	// before succeeding.
+	Flaky map[string]int
+	// Invalid lists the set bundles that include a card without assets.
+	Invalid map[string]bool
+}
+
+func newFakeCDN(versions ...string) *fakeCDN {
+	f := &fakeCDN{versions: map[string]bool{}, Published: map[string]bool{}, Broken: map[string]bool{}, Missing: map[string]bool{}, Flaky: map[string]int{}, Invalid: map[string]bool{}}
+	for _, v := range versions {
+		f.versions[strings.ReplaceAll(v, ".", "_")] = true
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	return f
+}
+
+func (f *fakeCDN) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	f.mu.Unlock()
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if len(parts) < 5 || !f.versions[parts[0]] {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	if parts[1] == "core" {
+		globals := repository.Globals{}
+		for _, s := range f.Sets {
+			globals.Sets = append(globals.Sets, repository.SetInfo{Name: s, NameRef: s})
+		}
+		_ = json.NewEncoder(w).Encode(globals)
+		return
+	}
+
+	if f.Broken[parts[1]] {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	f.mu.Lock()
+	flaky := f.Flaky[parts[1]] > 0
+	f.Flaky[parts[1]]--
+	f.mu.Unlock()
+	if flaky {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	if (len(f.Published) > 0 && !f.Published[parts[1]]) || f.Missing[parts[1]+"-"+parts[2]] {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	etag := `"` + parts[0] + parts[1] + `"`
+	w.Header().Set("ETag", etag)
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if r.Header.Get("If-None-Match") == etag || (err == nil && !lastModified.After(since)) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	cards := []*repository.Card{newCard(strings.ToUpper(parts[1])+"001", "card "+parts[2])}
+	if f.Invalid[parts[1]] {
+		cards = append(cards, &repository.Card{CardCode: strings.ToUpper(parts[1]) + "002", Name: "broken"})
+	}
+	_ = json.NewEncoder(w).Encode(cards)
+}
+
+// newCard returns a card that passes validation.
+func newCard(code string, name string) *repository.Card {
+	c := &repository.Card{CardCode: code, Name: name, Set: "Set1", RarityRef: "Common", RegionRefs: []string{"Demacia"}}
+	c.Assets = append(c.Assets, struct {
+		GameAbsolutePath string `json:"gameAbsolutePath"`
+		FullAbsolutePath string `json:"fullAbsolutePath"`
+	}{GameAbsolutePath: "http://dd/" + code + ".png", FullAbsolutePath: "http://dd/" + code + "-full.png"})
+	return c
+}
+
+func (f *fakeCDN) Requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string{}, f.requests...)
+}
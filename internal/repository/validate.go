@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// rarities are the rarity refs cards can be rendered with.
+var rarities = []string{"None", "Common", "Rare", "Epic", "Champion"}
+
+// Anomaly is a card that failed validation and was left out of its bundle.
+type Anomaly struct {
+	CardCode string
+	Set      string
+	Locale   string
+	Version  string
+	Reasons  []string
+	Card     *Card
+}
+
+// ValidateCard checks a card against the fields the bot needs to render it.
+// It returns the reasons the card is invalid, or nil if it is valid.
+func ValidateCard(c *Card) []string {
+	var reasons []string
+	check := func(ok bool, reason string, args ...any) {
+		if !ok {
+			reasons = append(reasons, fmt.Sprintf(reason, args...))
+		}
+	}
+
+	check(c.CardCode != "", "missing card code")
+	check(c.Name != "", "missing name")
+	check(c.Set != "", "missing set")
+	check(len(c.Assets) > 0, "missing assets")
+	for i, a := range c.Assets {
+		check(a.GameAbsolutePath != "" && a.FullAbsolutePath != "", "missing asset paths at %d", i)
+	}
+	check(len(c.RegionRefs) > 0, "missing region refs")
+	check(slices.Contains(rarities, c.RarityRef), "unknown rarity ref %q", c.RarityRef)
+	check(c.Cost >= 0 && c.Attack >= 0 && c.Health >= 0, "negative cost, attack or health")
+
+	return reasons
+}
+
+// validateBundle removes the invalid cards from the bundle and returns them
+// as anomalies.
+func validateBundle(bundle *SetBundle) []Anomaly {
+	var anomalies []Anomaly
+	valid := bundle.Cards[:0:0]
+	for _, c := range bundle.Cards {
+		if reasons := ValidateCard(c); len(reasons) > 0 {
+			anomalies = append(anomalies, Anomaly{
+				CardCode: c.CardCode,
+				Set:      bundle.Set,
+				Locale:   bundle.Locale,
+				Version:  bundle.Version,
+				Reasons:  reasons,
+				Card:     c,
+			})
+			continue
+		}
+		valid = append(valid, c)
+	}
+
+	bundle.Cards = valid
+	return anomalies
+}
+
+func sortAnomalies(anomalies []Anomaly) {
+	slices.SortFunc(anomalies, func(a, b Anomaly) int {
+		if c := cmp.Compare(a.Set, b.Set); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.Locale, b.Locale); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.CardCode, b.CardCode)
+	})
+}
@@ -0,0 +1,27 @@
+package repository_test
+
+import (
+	"github.com/dneto/sai-scout/internal/repository"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ValidateCard", func() {
+	It("accepts complete cards", func() {
+		Expect(repository.ValidateCard(newCard("01DE001", "Vanguard Bannerman"))).To(BeEmpty())
+	})
+
+	DescribeTable("rejects",
+		func(change func(c *repository.Card), reason string) {
+			c := newCard("01DE001", "Vanguard Bannerman")
+			change(c)
+			Expect(repository.ValidateCard(c)).To(ConsistOf(reason))
+		},
+		Entry("cards without assets", func(c *repository.Card) { c.Assets = nil }, "missing assets"),
+		Entry("assets without paths", func(c *repository.Card) { c.Assets[0].FullAbsolutePath = "" }, "missing asset paths at 0"),
+		Entry("cards without regions", func(c *repository.Card) { c.RegionRefs = nil }, "missing region refs"),
+		Entry("unknown rarities", func(c *repository.Card) { c.RarityRef = "Legendary" }, `unknown rarity ref "Legendary"`),
+		Entry("negative stats", func(c *repository.Card) { c.Cost = -1 }, "negative cost, attack or health"),
+		Entry("cards without name", func(c *repository.Card) { c.Name = "" }, "missing name"),
+	)
+})
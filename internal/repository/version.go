@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidVersion is returned when a bundle version is not in the
+// "major.minor.patch" format.
+var ErrInvalidVersion = errors.New("invalid version")
+
+type version [3]int
+
+func parseVersion(v string) (version, error) {
+	parts := strings.Split(strings.ReplaceAll(v, "_", "."), ".")
+	if len(parts) != 3 {
+		return version{}, fmt.Errorf("%w %q", ErrInvalidVersion, v)
+	}
+
+	var parsed version
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return version{}, fmt.Errorf("%w %q", ErrInvalidVersion, v)
+		}
+		parsed[i] = n
+	}
+
+	return parsed, nil
+}
+
+// ValidateVersion returns ErrInvalidVersion if v is not a bundle version like
+// "4.10.0".
+func ValidateVersion(v string) error {
+	_, err := parseVersion(v)
+	return err
+}
+
+func (v version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+}
+
+// key returns an integer that sorts like the version, so versions can be
+// compared inside database queries.
+func (v version) key() int {
+	return v[0]*1_000_000 + v[1]*1_000 + v[2]
+}
+
+// next returns the candidates that may follow v, in the order they should be
+// probed: next patch, next minor and next major.
+func (v version) next() []version {
+	return []version{
+		{v[0], v[1], v[2] + 1},
+		{v[0], v[1] + 1, 0},
+		{v[0] + 1, 0, 0},
+	}
+}
+
+// CompareVersions compares two bundle versions like "4.10.0". Invalid
+// versions are sorted before any valid one.
+func CompareVersions(a, b string) int {
+	va, errA := parseVersion(a)
+	vb, errB := parseVersion(b)
+	switch {
+	case errA != nil && errB != nil:
+		return cmp.Compare(a, b)
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+
+	for i := range va {
+		if c := cmp.Compare(va[i], vb[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
@@ -0,0 +1,245 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dneto/sai-scout/internal/cache"
+	"github.com/dneto/sai-scout/internal/i18n"
+	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
+)
+
+// limit is the number of cards returned by a search, the most Discord shows
+// as autocomplete choices.
+const limit = 25
+
+const (
+	// maxIndexes is the number of indexes kept, enough for every locale in a
+	// few versions.
+	maxIndexes = 64
+	// indexTTL is how long an index is kept, so the indexes of versions no
+	// longer searched are dropped.
+	indexTTL = 24 * time.Hour
+)
+
+// Source is the repository the cards are indexed from.
+type Source interface {
+	repository.CardRepository
+	CurrentVersion(ctx context.Context, locale string) (string, error)
+}
+
+type indexKey struct {
+	language, version string
+}
+
+// Cards wraps a card repository, searching names with an Index built from
+// the cards of each language and version searched.
+type Cards struct {
+	Source
+
+	// locales are the locales searched by SearchAllLocales.
+	locales []string
+
+	indexes *cache.LRU[indexKey, *Index]
+	builds  singleflight.Group
+
+	// mu guards generation, which is incremented by Invalidate so indexes
+	// built from the cards before it are not kept.
+	mu         sync.Mutex
+	generation uint64
+
+	// warming holds the warm-up waiting to run, so saving many bundles
+	// queues a single one.
+	warming chan struct{}
+	warmMu  sync.Mutex
+}
+
+func NewCards(src Source) *Cards {
+	return &Cards{
+		Source:  src,
+		locales: i18n.AsStringSlice(i18n.Locales),
+		indexes: cache.NewLRU[indexKey, *Index](maxIndexes, indexTTL),
+		warming: make(chan struct{}, 1),
+	}
+}
+
+// SearchByName searches cards by name as they were in the given version, or
+// in the current version of the language if version is empty.
+func (c *Cards) SearchByName(ctx context.Context, language string, version string, name string) ([]*repository.Card, error) {
+	idx, err := c.localeIndex(ctx, language, version)
+	if idx == nil || err != nil {
+		return nil, err
+	}
+	return idx.Search(name, limit), nil
+}
+
+// Filter returns the cards of a language matching match, as of a version or
+// of the current version if it is empty.
+func (c *Cards) Filter(ctx context.Context, language string, version string, match func(*repository.Card) bool) ([]*repository.Card, error) {
+	idx, err := c.localeIndex(ctx, language, version)
+	if idx == nil || err != nil {
+		return nil, err
+	}
+	return idx.Filter(match), nil
+}
+
+// Result is a card found by SearchAllLocales.
+type Result struct {
+	// Card is the card in the language searched for.
+	Card *repository.Card
+	// Matched is the name that matched the search, which differs from the
+	// card name when it matched in another locale.
+	Matched string
+}
+
+// SearchAllLocales searches cards by their name in every locale, returning
+// them in the given language, so people can type names in any language.
+// Cards matching equally well in the given language come first.
+func (c *Cards) SearchAllLocales(ctx context.Context, language string, version string, name string) ([]Result, error) {
+	target, err := c.localeIndex(ctx, language, version)
+	if err != nil {
+		return nil, err
+	}
+
+	type found struct {
+		Match
+		inTarget bool
+	}
+	var all []found
+	for _, locale := range c.locales {
+		idx, err := c.localeIndex(ctx, locale, version)
+		if err != nil {
+			return nil, err
+		}
+		if idx == nil {
+			continue
+		}
+		for _, m := range idx.Matches(name, limit) {
+			all = append(all, found{Match: m, inTarget: locale == language})
+		}
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		a, b := all[i], all[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.inTarget != b.inTarget {
+			return a.inTarget
+		}
+		return a.Card.Collectible && !b.Card.Collectible
+	})
+
+	seen := make(map[string]bool)
+	var results []Result
+	for _, f := range all {
+		if len(results) == limit {
+			break
+		}
+
+		card := f.Card
+		if target != nil {
+			if translated := target.Card(card.CardCode); translated != nil {
+				card = translated
+			}
+		}
+		if seen[card.Name] {
+			continue
+		}
+		seen[card.Name] = true
+		results = append(results, Result{Card: card, Matched: f.Card.Name})
+	}
+	return results, nil
+}
+
+// localeIndex returns the index of a language as of a version, or of the
+// current version if it is empty. It returns nil if the version is not
+// stored.
+func (c *Cards) localeIndex(ctx context.Context, language string, version string) (*Index, error) {
+	if version == "" {
+		current, err := c.CurrentVersion(ctx, language)
+		if err != nil || current == "" {
+			return nil, err
+		}
+		version = current
+	}
+
+	idx, err := c.index(ctx, language, version)
+	if errors.Is(err, repository.ErrVersionNotFound) {
+		return nil, nil
+	}
+	return idx, err
+}
+
+// index returns the index of a language and version, building it if it is
+// not kept. Concurrent searches of the same index wait for a single build,
+// and searches of other indexes don't wait for it.
+func (c *Cards) index(ctx context.Context, language string, version string) (*Index, error) {
+	key := indexKey{language: language, version: version}
+	if idx, ok := c.indexes.Get(key); ok {
+		return idx, nil
+	}
+
+	c.mu.Lock()
+	generation := c.generation
+	c.mu.Unlock()
+
+	v, err, _ := c.builds.Do(fmt.Sprintf("%d/%s/%s", generation, language, version), func() (any, error) {
+		cards, err := c.FindSnapshot(ctx, language, version)
+		if err != nil {
+			return nil, err
+		}
+		idx := NewIndex(cards)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.generation == generation {
+			c.indexes.Set(key, idx)
+		}
+		return idx, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*Index), nil
+}
+
+// Invalidate drops the indexes, so they are built again with the saved
+// cards, and builds the ones of the current version in the background.
+func (c *Cards) Invalidate() {
+	c.mu.Lock()
+	c.generation++
+	c.indexes.Purge()
+	c.mu.Unlock()
+	c.warm()
+}
+
+// warm builds the indexes of the current version of every locale, so the
+// first searches after bundles are saved don't wait for them. If a warm-up is
+// already waiting to run, it builds the new indexes instead.
+func (c *Cards) warm() {
+	select {
+	case c.warming <- struct{}{}:
+	default:
+		return
+	}
+
+	go func() {
+		c.warmMu.Lock()
+		defer c.warmMu.Unlock()
+		<-c.warming
+
+		ctx := context.Background()
+		for _, locale := range c.locales {
+			if _, err := c.localeIndex(ctx, locale, ""); err != nil {
+				log.Warn().Err(err).Str("locale", locale).Msg("Failed to build search index")
+			}
+		}
+	}()
+}
@@ -0,0 +1,146 @@
+package search_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/dneto/sai-scout/internal/search"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cards", func() {
+	var (
+		ctx   = context.Background()
+		repo  *repository.Memory
+		cards *search.Cards
+		save  func(context.Context, *repository.SetBundle) error
+	)
+
+	bundle := func(version string, lastModified time.Time, cards ...*repository.Card) *repository.SetBundle {
+		return &repository.SetBundle{Set: "set1", Locale: "en_us", Version: version, LastModified: lastModified, Cards: cards}
+	}
+
+	BeforeEach(func() {
+		repo = repository.NewMemory()
+		cards = search.NewCards(repo)
+		save = func(ctx context.Context, b *repository.SetBundle) error {
+			defer cards.Invalidate()
+			return repo.SaveBundle(ctx, b, false)
+		}
+		Expect(save(ctx, bundle("4.9.0", time.Now(), card("01IO001", "Jhin", true)))).To(Succeed())
+	})
+
+	It("searches the current version", func() {
+		found, err := cards.SearchByName(ctx, "en_us", "", "jhn")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(names(found)).To(Equal([]string{"Jhin"}))
+	})
+
+	It("indexes the saved cards again", func() {
+		_, err := cards.SearchByName(ctx, "en_us", "", "jhin")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(save(ctx, bundle("4.10.0", time.Now(), card("01IO001", "Jhin", true), card("01IO002", "Jhin's Whisper", true)))).To(Succeed())
+		found, err := cards.SearchByName(ctx, "en_us", "", "jhin")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(names(found)).To(Equal([]string{"Jhin", "Jhin's Whisper"}))
+
+		found, err = cards.SearchByName(ctx, "en_us", "4.9.0", "jhin")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(names(found)).To(Equal([]string{"Jhin"}))
+	})
+
+	It("finds nothing in versions not stored", func() {
+		found, err := cards.SearchByName(ctx, "en_us", "5.0.0", "jhin")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeEmpty())
+
+		found, err = cards.SearchByName(ctx, "pt_br", "", "jhin")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeEmpty())
+	})
+
+	It("searches names in every locale", func() {
+		ptBR := bundle("4.9.0", time.Now(), card("01IO001", "Jhin (pt)", true))
+		ptBR.Locale = "pt_br"
+		Expect(save(ctx, ptBR)).To(Succeed())
+
+		found, err := cards.SearchAllLocales(ctx, "en_us", "", "jhin (pt)")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(HaveLen(1))
+		Expect(found[0].Card.Name).To(Equal("Jhin"))
+		Expect(found[0].Matched).To(Equal("Jhin (pt)"))
+
+		found, err = cards.SearchAllLocales(ctx, "pt_br", "", "jhin")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(HaveLen(1))
+		Expect(found[0].Card.Name).To(Equal("Jhin (pt)"))
+	})
+
+	It("fails on invalid versions", func() {
+		_, err := cards.SearchByName(ctx, "en_us", "latest", "jhin")
+		Expect(err).To(MatchError(repository.ErrInvalidVersion))
+	})
+
+	Context("building indexes", func() {
+		var src *countingSource
+
+		BeforeEach(func() {
+			src = &countingSource{Memory: repo}
+			cards = search.NewCards(src)
+		})
+
+		It("builds each index once for concurrent searches", func() {
+			src.gate = make(chan struct{})
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					found, err := cards.SearchByName(ctx, "en_us", "4.9.0", "jhin")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(names(found)).To(Equal([]string{"Jhin"}))
+				}()
+			}
+			Eventually(src.snapshots.Load).Should(BeEquivalentTo(1))
+			Consistently(src.snapshots.Load, "50ms").Should(BeEquivalentTo(1))
+			close(src.gate)
+			wg.Wait()
+
+			_, err := cards.SearchByName(ctx, "en_us", "4.9.0", "jhin")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(src.snapshots.Load()).To(BeEquivalentTo(1))
+		})
+
+		It("builds the indexes of the current version after they are invalidated", func() {
+			cards.Invalidate()
+			Eventually(src.snapshots.Load).Should(BeNumerically(">=", 1))
+
+			before := src.snapshots.Load()
+			_, err := cards.SearchByName(ctx, "en_us", "", "jhin")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(src.snapshots.Load()).To(Equal(before))
+		})
+	})
+})
+
+// countingSource counts the snapshots read to build indexes, waiting for
+// gate to be closed to read them if it is set.
+type countingSource struct {
+	*repository.Memory
+	snapshots atomic.Int64
+	gate      chan struct{}
+}
+
+func (s *countingSource) FindSnapshot(ctx context.Context, language string, version string) ([]*repository.Card, error) {
+	s.snapshots.Add(1)
+	if s.gate != nil {
+		<-s.gate
+	}
+	return s.Memory.FindSnapshot(ctx, language, version)
+}
@@ -0,0 +1,409 @@
+// Package search finds cards by name, tolerating typos, missing accents and
+// incomplete words, in every locale the bot supports.
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/dneto/sai-scout/internal/repository"
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
+)
+
+// Match quality levels. A card is ranked by the best level its name reaches.
+const (
+	scoreExact     = 100
+	scorePrefix    = 90
+	scoreWords     = 80
+	scoreSubstring = 70
+	scoreFuzzy     = 60
+	scoreGrams     = 40
+)
+
+// minSimilarity is the share of n-grams a query must have in common with a
+// name to match it.
+const minSimilarity = 0.5
+
+// foldable are the scripts whose diacritics are dropped. Marks of other
+// scripts, like Thai vowels or the Japanese voicing marks, change the letter
+// and are kept.
+var foldable = []*unicode.RangeTable{unicode.Latin, unicode.Greek, unicode.Cyrillic}
+
+// dense are the scripts written without spaces between words. Their names
+// are indexed by characters and pairs of characters instead of trigrams.
+var dense = []*unicode.RangeTable{unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul, unicode.Thai}
+
+// Fold normalizes s for matching: it is lowercased, full-width letters become
+// regular ones and the diacritics of latin, greek and cyrillic letters are
+// dropped, so "Émile" and "ｅｍｉｌｅ" both become "emile".
+func Fold(s string) string {
+	var b strings.Builder
+	foldMarks := false
+	for _, r := range norm.NFD.String(width.Fold.String(s)) {
+		if unicode.Is(unicode.Mn, r) {
+			if !foldMarks {
+				b.WriteRune(r)
+			}
+			continue
+		}
+		foldMarks = unicode.In(r, foldable...)
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return norm.NFC.String(b.String())
+}
+
+// tokenize splits a folded name into words. Runs of dense scripts are kept as
+// single words.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r)
+	})
+}
+
+func isDense(token string) bool {
+	for _, r := range token {
+		if unicode.In(r, dense...) {
+			return true
+		}
+	}
+	return false
+}
+
+// grams returns the n-grams of a word used to find names with typos:
+// trigrams of the word padded with spaces, or the characters and pairs of
+// characters of dense words.
+func grams(token string) []string {
+	rs := []rune(token)
+	if isDense(token) {
+		gs := make([]string, 0, 2*len(rs))
+		for i := range rs {
+			gs = append(gs, string(rs[i]))
+			if i+1 < len(rs) {
+				gs = append(gs, string(rs[i:i+2]))
+			}
+		}
+		return gs
+	}
+
+	padded := []rune(" " + token + " ")
+	if len(padded) < 3 {
+		return []string{string(padded)}
+	}
+	gs := make([]string, 0, len(padded)-2)
+	for i := 0; i+3 <= len(padded); i++ {
+		gs = append(gs, string(padded[i:i+3]))
+	}
+	return gs
+}
+
+func gramSet(tokens []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, t := range tokens {
+		for _, g := range grams(t) {
+			set[g] = true
+		}
+	}
+	return set
+}
+
+type entry struct {
+	card   *repository.Card
+	name   string
+	tokens []string
+	grams  map[string]bool
+}
+
+type tokenRef struct {
+	token string
+	entry int
+}
+
+// Index finds cards by name. It is built once from the cards of a locale and
+// is safe for concurrent searches.
+type Index struct {
+	entries []entry
+	// byCode has the first card of each code.
+	byCode map[string]*repository.Card
+	// tokens are the words of every name, sorted to find them by prefix.
+	tokens []tokenRef
+	// postings lists the entries of each n-gram.
+	postings map[string][]int
+}
+
+func NewIndex(cards []*repository.Card) *Index {
+	idx := &Index{postings: make(map[string][]int), byCode: make(map[string]*repository.Card)}
+	for _, c := range cards {
+		if _, ok := idx.byCode[c.CardCode]; !ok {
+			idx.byCode[c.CardCode] = c
+		}
+
+		name := Fold(c.Name)
+		tokens := tokenize(name)
+		e := entry{card: c, name: strings.Join(tokens, " "), tokens: tokens, grams: gramSet(tokens)}
+
+		i := len(idx.entries)
+		idx.entries = append(idx.entries, e)
+		for _, t := range tokens {
+			idx.tokens = append(idx.tokens, tokenRef{token: t, entry: i})
+		}
+		for g := range e.grams {
+			idx.postings[g] = append(idx.postings[g], i)
+		}
+	}
+
+	sort.Slice(idx.tokens, func(i, j int) bool {
+		return idx.tokens[i].token < idx.tokens[j].token
+	})
+	return idx
+}
+
+type result struct {
+	entry *entry
+	score int
+}
+
+// Card returns a copy of the indexed card with the given code, or nil if
+// there is none.
+func (idx *Index) Card(code string) *repository.Card {
+	c, ok := idx.byCode[code]
+	if !ok {
+		return nil
+	}
+	found := *c
+	return &found
+}
+
+// Filter returns copies of the indexed cards matching match, in the order
+// they were indexed. Cards with the same code are returned once.
+func (idx *Index) Filter(match func(*repository.Card) bool) []*repository.Card {
+	var cards []*repository.Card
+	for _, e := range idx.entries {
+		if idx.byCode[e.card.CardCode] != e.card || !match(e.card) {
+			continue
+		}
+		found := *e.card
+		cards = append(cards, &found)
+	}
+	return cards
+}
+
+// Search returns up to limit cards matching query, best matches first. Cards
+// matching equally well are sorted with collectible cards first, then by the
+// length of the name. Cards with the same name are returned once.
+func (idx *Index) Search(query string, limit int) []*repository.Card {
+	matches := idx.Matches(query, limit)
+	if matches == nil {
+		return nil
+	}
+	cards := make([]*repository.Card, len(matches))
+	for i, m := range matches {
+		cards[i] = m.Card
+	}
+	return cards
+}
+
+// Match is a card found by a search and how well its name matched, from 1 to
+// 100.
+type Match struct {
+	Card  *repository.Card
+	Score int
+}
+
+// Matches is like Search, returning how well each card matched.
+func (idx *Index) Matches(query string, limit int) []Match {
+	tokens := tokenize(Fold(query))
+	if len(tokens) == 0 {
+		return nil
+	}
+	q := strings.Join(tokens, " ")
+	qGrams := gramSet(tokens)
+
+	var results []result
+	for i := range idx.candidates(tokens, qGrams) {
+		e := &idx.entries[i]
+		if s := score(q, tokens, qGrams, e); s > 0 {
+			results = append(results, result{entry: e, score: s})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if a.entry.card.Collectible != b.entry.card.Collectible {
+			return a.entry.card.Collectible
+		}
+		if len(a.entry.name) != len(b.entry.name) {
+			return len(a.entry.name) < len(b.entry.name)
+		}
+		if a.entry.name != b.entry.name {
+			return a.entry.name < b.entry.name
+		}
+		return a.entry.card.CardCode < b.entry.card.CardCode
+	})
+
+	seen := make(map[string]bool)
+	var matches []Match
+	for _, r := range results {
+		if len(matches) == limit {
+			break
+		}
+		if seen[r.entry.card.Name] {
+			continue
+		}
+		seen[r.entry.card.Name] = true
+		c := *r.entry.card
+		matches = append(matches, Match{Card: &c, Score: r.score})
+	}
+	return matches
+}
+
+// candidates returns the entries sharing a word prefix or an n-gram with the
+// query.
+func (idx *Index) candidates(tokens []string, qGrams map[string]bool) map[int]bool {
+	found := make(map[int]bool)
+	for _, t := range tokens {
+		i := sort.Search(len(idx.tokens), func(i int) bool {
+			return idx.tokens[i].token >= t
+		})
+		for ; i < len(idx.tokens) && strings.HasPrefix(idx.tokens[i].token, t); i++ {
+			found[idx.tokens[i].entry] = true
+		}
+	}
+	for g := range qGrams {
+		for _, i := range idx.postings[g] {
+			found[i] = true
+		}
+	}
+	return found
+}
+
+// score returns how well a name matches the query, or zero if it does not.
+func score(q string, tokens []string, qGrams map[string]bool, e *entry) int {
+	switch {
+	case e.name == q:
+		return scoreExact
+	case strings.HasPrefix(e.name, q):
+		return scorePrefix
+	case allTokens(tokens, e.tokens, strings.HasPrefix):
+		return scoreWords
+	case strings.Contains(e.name, q):
+		return scoreSubstring
+	}
+
+	if edits, ok := fuzzyTokens(tokens, e.tokens); ok {
+		return scoreFuzzy - edits
+	}
+
+	if sim := similarity(qGrams, e.grams); sim >= minSimilarity {
+		return scoreGrams + int(10*sim)
+	}
+	return 0
+}
+
+// allTokens reports whether every query word matches a word of the name.
+func allTokens(query []string, name []string, match func(name string, query string) bool) bool {
+	for _, q := range query {
+		found := false
+		for _, n := range name {
+			if match(n, q) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// maxEdits is the number of typos tolerated in a word.
+func maxEdits(word string) int {
+	switch n := len([]rune(word)); {
+	case n <= 2:
+		return 0
+	case n <= 5:
+		return 1
+	}
+	return 2
+}
+
+// fuzzyTokens reports whether every query word is close to a word of the
+// name, or to its beginning, returning the total number of typos.
+func fuzzyTokens(query []string, name []string) (int, bool) {
+	total := 0
+	for _, q := range query {
+		max := maxEdits(q)
+		best := max + 1
+		for _, n := range name {
+			if d := prefixDistance(q, n); d < best {
+				best = d
+			}
+		}
+		if best > max {
+			return 0, false
+		}
+		total += best
+	}
+	return total, true
+}
+
+// prefixDistance returns the smallest distance between the query word and
+// the name word or a prefix of it about as long as the query.
+func prefixDistance(q string, n string) int {
+	qr, nr := []rune(q), []rune(n)
+	best := distance(qr, nr)
+	for l := len(qr) - 1; l <= len(qr)+1; l++ {
+		if l > 0 && l < len(nr) {
+			if d := distance(qr, nr[:l]); d < best {
+				best = d
+			}
+		}
+	}
+	return best
+}
+
+// distance is the Damerau-Levenshtein distance (optimal string alignment)
+// between a and b, counting swapped letters as one typo.
+func distance(a []rune, b []rune) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// similarity is the Dice coefficient of the query and name n-grams.
+func similarity(a map[string]bool, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for g := range a {
+		if b[g] {
+			common++
+		}
+	}
+	return 2 * float64(common) / float64(len(a)+len(b))
+}
@@ -0,0 +1,82 @@
+package search_test
+
+import (
+	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/dneto/sai-scout/internal/search"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fold", func() {
+	DescribeTable("normalizes names",
+		func(name string, folded string) {
+			Expect(search.Fold(name)).To(Equal(folded))
+		},
+		Entry("lowercase", "Annie", "annie"),
+		Entry("latin diacritics", "Fúria Lunar", "furia lunar"),
+		Entry("full-width letters", "ＡＮＮＩＥ", "annie"),
+		Entry("japanese voicing marks", "ガレン", "ガレン"),
+		Entry("thai marks", "แอนนี่", "แอนนี่"),
+	)
+})
+
+var _ = Describe("Index", func() {
+	idx := search.NewIndex([]*repository.Card{
+		card("01NX001", "Annie's Fireball", true),
+		card("06NX005", "Annie", true),
+		card("06NX005T1", "Annie", false),
+		card("06NX006", "Tibbers", false),
+		card("01IO001", "Jhin", true),
+		card("01DE001", "Vanguard Defender", true),
+		card("01DE002", "Vanguard Sergeant", false),
+		card("01DE003", "Vanguard Redeemer", true),
+		card("02PZ001", "Fúria Lunar", true),
+		card("03JA001", "アニー", true),
+		card("03ZH001", "安妮", true),
+		card("03KO001", "애니", true),
+		card("03TH001", "แอนนี่", true),
+	})
+
+	DescribeTable("finds cards",
+		func(query string, expected ...string) {
+			Expect(names(idx.Search(query, 25))).To(Equal(expected))
+		},
+		Entry("by exact name", "annie", "Annie", "Annie's Fireball"),
+		Entry("by name prefix", "Ann", "Annie", "Annie's Fireball"),
+		Entry("by word prefix", "fire", "Annie's Fireball"),
+		Entry("by words in any order", "def vang", "Vanguard Defender"),
+		Entry("with typos", "Jhn", "Jhin"),
+		Entry("with swapped letters", "Tibebrs", "Tibbers"),
+		Entry("without accents", "furia", "Fúria Lunar"),
+		Entry("with accents", "lúnár", "Fúria Lunar"),
+		Entry("in japanese", "アニ", "アニー"),
+		Entry("in chinese", "妮", "安妮"),
+		Entry("in korean", "애", "애니"),
+		Entry("in thai", "แอน", "แอนนี่"),
+		Entry("nothing for unrelated names", "Teemo"),
+		Entry("nothing for empty queries", " "),
+	)
+
+	It("ranks collectible cards first among equal matches", func() {
+		Expect(names(idx.Search("vanguard", 25))).To(Equal([]string{
+			"Vanguard Defender", "Vanguard Redeemer", "Vanguard Sergeant",
+		}))
+	})
+
+	It("returns cards with the same name once, preferring collectible ones", func() {
+		cards := idx.Search("annie", 25)
+		Expect(cards[0].CardCode).To(Equal("06NX005"))
+	})
+
+	It("limits the results", func() {
+		Expect(idx.Search("vanguard", 2)).To(HaveLen(2))
+	})
+
+	It("filters the cards", func() {
+		cards := idx.Filter(func(c *repository.Card) bool { return !c.Collectible })
+		Expect(names(cards)).To(Equal([]string{"Annie", "Tibbers", "Vanguard Sergeant"}))
+
+		cards[0].Name = "changed"
+		Expect(idx.Card("06NX005T1").Name).To(Equal("Annie"))
+	})
+})
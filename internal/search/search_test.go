@@ -0,0 +1,26 @@
+package search_test
+
+import (
+	"testing"
+
+	"github.com/dneto/sai-scout/internal/repository"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSearch(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Search Suite")
+}
+
+func card(code string, name string, collectible bool) *repository.Card {
+	return &repository.Card{CardCode: code, Name: name, Collectible: collectible}
+}
+
+func names(cards []*repository.Card) []string {
+	names := make([]string, len(cards))
+	for i, c := range cards {
+		names[i] = c.Name
+	}
+	return names
+}
@@ -0,0 +1,142 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/rs/zerolog/log"
+)
+
+// Storage holds the functions used to read and save bundles.
+type Storage struct {
+	SaveBundle  func(context.Context, *repository.SetBundle) error
+	SaveGlobals func(context.Context, *repository.Globals) error
+	FindBundle  func(ctx context.Context, set string, locale string) (*repository.SetBundle, error)
+	Quarantine  func(context.Context, []repository.Anomaly) error
+}
+
+// Syncer keeps the stored set bundles up to date with the latest version
+// published on Data Dragon.
+type Syncer struct {
+	dd       *repository.DataDragon
+	storage  Storage
+	onUpdate func(version string)
+	onSaved  func()
+
+	mu      sync.Mutex
+	version string
+}
+
+func New(dd *repository.DataDragon, version string, storage Storage, onUpdate func(version string)) *Syncer {
+	if onUpdate == nil {
+		onUpdate = func(string) {}
+	}
+	return &Syncer{dd: dd, storage: storage, onUpdate: onUpdate, onSaved: func() {}, version: version}
+}
+
+// WithSavedFunc sets a function called once after each sync that saved set
+// bundles, e.g. to drop what was computed from the previous ones.
+func (s *Syncer) WithSavedFunc(saved func()) *Syncer {
+	s.onSaved = saved
+	return s
+}
+
+// Version returns the last version successfully synced.
+func (s *Syncer) Version() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.version
+}
+
+// Sync discovers the latest published version and saves its core and set
+// bundles.
+// Bundles that did not change are left untouched by the save function. Set
+// bundles are saved even if the globals fail, and the version is kept until
+// both are saved.
+func (s *Syncer) Sync(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	latest, err := s.dd.LatestVersion(ctx, s.version)
+	if err != nil {
+		return fmt.Errorf("failed to discover latest version: %w", err)
+	}
+	return s.syncVersion(ctx, latest)
+}
+
+// SyncVersion saves the core and set bundles of a version without looking
+// for newer ones.
+func (s *Syncer) SyncVersion(ctx context.Context, version string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.syncVersion(ctx, version)
+}
+
+func (s *Syncer) syncVersion(ctx context.Context, version string) error {
+	log.Info().Str("version", version).Msg("syncing bundles")
+	params := repository.UpdateParams{Version: version, FindBundle: s.storage.FindBundle, Quarantine: s.storage.Quarantine}
+	globalsErr := s.dd.UpdateGlobals(ctx, params, s.storage.SaveGlobals)
+	if globalsErr != nil {
+		log.Error().Err(globalsErr).Str("version", version).Msg("failed to sync globals")
+	}
+
+	report, err := s.dd.UpdateSetBundles(ctx, params, s.storage.SaveBundle)
+	if report != nil && report.Count(repository.OutcomeUpdated) > 0 {
+		s.onSaved()
+	}
+	if report != nil {
+		log.Info().Str("version", version).
+			Strs("found", report.Found).
+			Strs("skipped", report.Skipped).
+			Strs("missing", report.Missing).
+			Int("updated", report.Count(repository.OutcomeUpdated)).
+			Int("unchanged", report.Count(repository.OutcomeUnchanged)).
+			Int("failed", report.Count(repository.OutcomeFailed)).
+			Int("quarantined", len(report.Anomalies)).
+			Msg("set bundles synced")
+		for _, a := range report.Anomalies {
+			log.Warn().Str("set", a.Set).Str("locale", a.Locale).Str("card", a.CardCode).
+				Strs("reasons", a.Reasons).Msg("card quarantined")
+		}
+	}
+	if err := errors.Join(globalsErr, err); err != nil {
+		return err
+	}
+
+	if version != s.version {
+		s.version = version
+		s.onUpdate(version)
+	}
+
+	return nil
+}
+
+// Run syncs immediately and then once every interval until ctx is done. It
+// syncs only once if interval is not positive.
+func (s *Syncer) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		if err := s.Sync(ctx); err != nil {
+			log.Error().Err(err).Msg("failed to sync set bundles")
+		}
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Sync(ctx); err != nil {
+			log.Error().Err(err).Msg("failed to sync set bundles")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package syncer_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dneto/sai-scout/internal/i18n"
+	"github.com/dneto/sai-scout/internal/repository"
+	"github.com/dneto/sai-scout/internal/syncer"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSyncer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Syncer Suite")
+}
+
+var _ = Describe("Syncer", func() {
+	var (
+		ctx      = context.Background()
+		server   *httptest.Server
+		mu       sync.Mutex
+		versions []string
+		saved    []string
+		globals  []string
+		updates  []string
+		synced   int
+		saveErr  error
+		globErr  error
+		target   *syncer.Syncer
+	)
+
+	BeforeEach(func() {
+		versions = []string{"4_10_0", "4_11_0"}
+		saved, globals, updates, saveErr, globErr, synced = nil, nil, nil, nil, nil, 0
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			for _, v := range versions {
+				if strings.HasPrefix(r.URL.Path, "/"+v+"/core/") {
+					_, _ = w.Write([]byte("{}"))
+					return
+				}
+				if strings.HasPrefix(r.URL.Path, "/"+v+"/") {
+					_, _ = w.Write([]byte("[]"))
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+		}))
+
+		dd := repository.NewDataDragon(server.URL, server.Client()).WithRetries(0, 0)
+		save := func(_ context.Context, b *repository.SetBundle) error {
+			mu.Lock()
+			defer mu.Unlock()
+			saved = append(saved, b.Version)
+			return saveErr
+		}
+		saveGlobals := func(_ context.Context, g *repository.Globals) error {
+			mu.Lock()
+			defer mu.Unlock()
+			globals = append(globals, g.Locale)
+			return globErr
+		}
+		target = syncer.New(dd, "4.10.0", syncer.Storage{SaveBundle: save, SaveGlobals: saveGlobals}, func(v string) {
+			updates = append(updates, v)
+		}).WithSavedFunc(func() {
+			synced++
+		})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("saves bundles of the latest version", func() {
+		Expect(target.Sync(ctx)).To(Succeed())
+		Expect(saved).ToNot(BeEmpty())
+		Expect(saved).To(HaveEach("4.11.0"))
+	})
+
+	It("saves globals of every locale", func() {
+		Expect(target.Sync(ctx)).To(Succeed())
+		Expect(globals).To(ConsistOf(i18n.AsStringSlice(i18n.Locales)))
+	})
+
+	It("notifies the new version", func() {
+		Expect(target.Sync(ctx)).To(Succeed())
+		Expect(target.Version()).To(Equal("4.11.0"))
+		Expect(updates).To(Equal([]string{"4.11.0"}))
+	})
+
+	It("does not notify when the version did not change", func() {
+		Expect(target.Sync(ctx)).To(Succeed())
+		Expect(target.Sync(ctx)).To(Succeed())
+		Expect(updates).To(Equal([]string{"4.11.0"}))
+	})
+
+	It("picks up versions published later", func() {
+		Expect(target.Sync(ctx)).To(Succeed())
+		mu.Lock()
+		versions = append(versions, "4_11_1")
+		mu.Unlock()
+		Expect(target.Sync(ctx)).To(Succeed())
+		Expect(updates).To(Equal([]string{"4.11.0", "4.11.1"}))
+	})
+
+	It("saves bundles of a given version without looking for newer ones", func() {
+		Expect(target.SyncVersion(ctx, "4.10.0")).To(Succeed())
+		Expect(saved).ToNot(BeEmpty())
+		Expect(saved).To(HaveEach("4.10.0"))
+		Expect(updates).To(BeEmpty())
+	})
+
+	It("calls the saved func once per sync that saved bundles", func() {
+		Expect(target.Sync(ctx)).To(Succeed())
+		Expect(len(saved)).To(BeNumerically(">", 1))
+		Expect(synced).To(Equal(1))
+	})
+
+	It("syncs once when the interval is not positive", func() {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			target.Run(ctx, 0)
+		}()
+		Eventually(done).Should(BeClosed())
+		Expect(target.Version()).To(Equal("4.11.0"))
+	})
+
+	It("keeps the current version when saving fails", func() {
+		saveErr = errors.New("fatal error")
+		Expect(target.Sync(ctx)).ToNot(Succeed())
+		Expect(target.Version()).To(Equal("4.10.0"))
+		Expect(updates).To(BeEmpty())
+	})
+
+	It("saves bundles when saving globals fails", func() {
+		globErr = errors.New("fatal error")
+		Expect(target.Sync(ctx)).To(MatchError(ContainSubstring("fatal error")))
+		Expect(saved).ToNot(BeEmpty())
+		Expect(target.Version()).To(Equal("4.10.0"))
+		Expect(updates).To(BeEmpty())
+	})
+})
@@ -20,3 +20,62 @@ func AddFields(me *discordgo.MessageEmbed) func(...*discordgo.MessageEmbedField)
 		me.Fields = append(me.Fields, mef...)
 	}
 }
+
+// Discord limits for embeds and messages.
+const (
+	MaxFields           = 25
+	MaxFieldValue       = 1024
+	MaxEmbedsPerMessage = 10
+	MaxMessageSize      = 6000
+)
+
+// Truncate cuts s to at most n characters, ending it with "…" when cut.
+func Truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// Size returns the number of characters of the embed that count towards the
+// message size limit.
+func Size(me *discordgo.MessageEmbed) int {
+	size := len([]rune(me.Title)) + len([]rune(me.Description))
+	if me.Footer != nil {
+		size += len([]rune(me.Footer.Text))
+	}
+	if me.Author != nil {
+		size += len([]rune(me.Author.Name))
+	}
+	for _, f := range me.Fields {
+		size += fieldSize(f)
+	}
+	return size
+}
+
+func fieldSize(f *discordgo.MessageEmbedField) int {
+	return len([]rune(f.Name)) + len([]rune(f.Value))
+}
+
+// Paginate splits fields into as many embeds with the given title as needed
+// to fit the fields and size limits. Each embed is sized to be sent alone,
+// leaving room for a footer.
+func Paginate(title string, fields []*discordgo.MessageEmbedField) []*discordgo.MessageEmbed {
+	const footerRoom = 100
+
+	var pages []*discordgo.MessageEmbed
+	page := &discordgo.MessageEmbed{Title: title}
+	for _, f := range fields {
+		full := len(page.Fields) == MaxFields || Size(page)+fieldSize(f) > MaxMessageSize-footerRoom
+		if full && len(page.Fields) > 0 {
+			pages = append(pages, page)
+			page = &discordgo.MessageEmbed{Title: title}
+		}
+		page.Fields = append(page.Fields, f)
+	}
+	if len(page.Fields) > 0 {
+		pages = append(pages, page)
+	}
+	return pages
+}
@@ -0,0 +1,181 @@
+package discord
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Paging actions, the last part of the custom ID of the paging buttons.
+const (
+	pageFirst    = "first"
+	pagePrevious = "prev"
+	pageNext     = "next"
+	pageLast     = "last"
+)
+
+// Paginator sends embeds one page at a time, with buttons to go to the
+// first, previous, next and last pages. Only the user who ran the command can
+// change pages, until the pages expire.
+//
+// The custom ID of the buttons starts with the command name, so clicks are
+// routed to the command by HandleInteractionCreate, whose handler is wrapped
+// by Wrap to change the pages.
+type Paginator struct {
+	command string
+	ttl     time.Duration
+
+	mu    sync.Mutex
+	pages map[string]*pages
+}
+
+type pages struct {
+	user    string
+	embeds  []*discordgo.MessageEmbed
+	current int
+	expires time.Time
+}
+
+// NewPaginator returns a paginator for the pages of a command, which expire
+// after ttl.
+func NewPaginator(command string, ttl time.Duration) *Paginator {
+	return &Paginator{command: command, ttl: ttl, pages: make(map[string]*pages)}
+}
+
+// Send sends the first page as a followup message of an interaction. There
+// are no buttons if there is only one page.
+func (p *Paginator) Send(s Session, i *discordgo.InteractionCreate, embeds []*discordgo.MessageEmbed) error {
+	if len(embeds) == 0 {
+		return nil
+	}
+
+	params := &discordgo.WebhookParams{Embeds: embeds[:1]}
+	if len(embeds) > 1 {
+		id, err := newPagesID()
+		if err != nil {
+			return err
+		}
+
+		p.mu.Lock()
+		p.expire()
+		pg := &pages{user: UserID(i), embeds: embeds, expires: time.Now().Add(p.ttl)}
+		p.pages[id] = pg
+		params.Components = p.buttons(id, pg)
+		p.mu.Unlock()
+	}
+
+	_, err := s.FollowupMessageCreate(i.Interaction, false, params)
+	return err
+}
+
+// Wrap returns a handler changing the pages when their buttons are clicked,
+// and handling the other interactions of the command with h.
+func (p *Paginator) Wrap(h Handler) Handler {
+	return func(s Session, i *discordgo.InteractionCreate) error {
+		if i.Type == discordgo.InteractionMessageComponent &&
+			strings.HasPrefix(i.MessageComponentData().CustomID, p.command+";page;") {
+			return p.handle(s, i)
+		}
+		return h(s, i)
+	}
+}
+
+// handle changes the page of a message when one of its buttons is clicked.
+// The buttons of expired pages are removed.
+func (p *Paginator) handle(s Session, i *discordgo.InteractionCreate) error {
+	split := strings.Split(i.MessageComponentData().CustomID, ";")
+	if len(split) != 4 {
+		return fmt.Errorf("invalid paging button %q", i.MessageComponentData().CustomID)
+	}
+	id, action := split[2], split[3]
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pg, ok := p.pages[id]
+	if !ok || time.Now().After(pg.expires) {
+		delete(p.pages, id)
+		data := &discordgo.InteractionResponseData{Components: []discordgo.MessageComponent{}}
+		if i.Message != nil {
+			data.Embeds = i.Message.Embeds
+		}
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: data,
+		})
+	}
+
+	if UserID(i) != pg.user {
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Flags:   discordgo.MessageFlagsEphemeral,
+				Content: "Only the user who ran the command can change pages",
+			},
+		})
+	}
+
+	switch action {
+	case pageFirst:
+		pg.current = 0
+	case pagePrevious:
+		pg.current = max(pg.current-1, 0)
+	case pageNext:
+		pg.current = min(pg.current+1, len(pg.embeds)-1)
+	case pageLast:
+		pg.current = len(pg.embeds) - 1
+	}
+
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{pg.embeds[pg.current]},
+			Components: p.buttons(id, pg),
+		},
+	})
+}
+
+// expire drops the expired pages. It must be called with the lock held.
+func (p *Paginator) expire() {
+	now := time.Now()
+	for id, pg := range p.pages {
+		if now.After(pg.expires) {
+			delete(p.pages, id)
+		}
+	}
+}
+
+func (p *Paginator) buttons(id string, pg *pages) []discordgo.MessageComponent {
+	first, last := pg.current == 0, pg.current == len(pg.embeds)-1
+	button := func(action string, emoji string, disabled bool) discordgo.MessageComponent {
+		return discordgo.Button{
+			Style:    discordgo.SecondaryButton,
+			Emoji:    discordgo.ComponentEmoji{Name: emoji},
+			Disabled: disabled,
+			CustomID: fmt.Sprintf("%s;page;%s;%s", p.command, id, action),
+		}
+	}
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			button(pageFirst, "⏮️", first),
+			button(pagePrevious, "◀️", first),
+			button(pageNext, "▶️", last),
+			button(pageLast, "⏭️", last),
+		}},
+	}
+}
+
+// newPagesID returns a random ID, so buttons of messages sent before a
+// restart don't change the pages of new ones.
+func newPagesID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,186 @@
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Paginator", func() {
+	var (
+		paginator *Paginator
+		session   *fakeSession
+		handled   int
+		handler   Handler
+	)
+
+	embeds := func(n int) []*discordgo.MessageEmbed {
+		e := make([]*discordgo.MessageEmbed, n)
+		for i := range e {
+			e[i] = &discordgo.MessageEmbed{Title: fmt.Sprintf("page %d", i+1)}
+		}
+		return e
+	}
+
+	command := func(user string) *discordgo.InteractionCreate {
+		return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			Type:   discordgo.InteractionApplicationCommand,
+			Member: &discordgo.Member{User: &discordgo.User{ID: user}},
+			Data:   discordgo.ApplicationCommandInteractionData{Name: "search"},
+		}}
+	}
+
+	buttons := func(components []discordgo.MessageComponent) []discordgo.Button {
+		GinkgoHelper()
+		Expect(components).To(HaveLen(1))
+		var b []discordgo.Button
+		for _, c := range components[0].(discordgo.ActionsRow).Components {
+			b = append(b, c.(discordgo.Button))
+		}
+		return b
+	}
+
+	disabled := func(b []discordgo.Button) []bool {
+		d := make([]bool, len(b))
+		for i := range b {
+			d[i] = b[i].Disabled
+		}
+		return d
+	}
+
+	click := func(user string, customID string) error {
+		message := &discordgo.Message{}
+		if len(session.followUps) > 0 {
+			message.Embeds = session.followUps[0].Embeds
+		}
+		return handler(session, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			Type:    discordgo.InteractionMessageComponent,
+			Member:  &discordgo.Member{User: &discordgo.User{ID: user}},
+			Data:    discordgo.MessageComponentInteractionData{CustomID: customID},
+			Message: message,
+		}})
+	}
+
+	last := func() *discordgo.InteractionResponse {
+		return session.responses[len(session.responses)-1]
+	}
+
+	send := func(n int) []discordgo.Button {
+		GinkgoHelper()
+		Expect(paginator.Send(session, command("user"), embeds(n))).To(Succeed())
+		return buttons(session.followUps[0].Components)
+	}
+
+	BeforeEach(func() {
+		paginator = NewPaginator("search", time.Minute)
+		session = &fakeSession{}
+		handled = 0
+		handler = paginator.Wrap(func(s Session, i *discordgo.InteractionCreate) error {
+			handled++
+			return nil
+		})
+	})
+
+	It("sends a single page without buttons", func() {
+		Expect(paginator.Send(session, command("user"), embeds(1))).To(Succeed())
+		Expect(session.followUps).To(HaveLen(1))
+		Expect(session.followUps[0].Embeds).To(Equal(embeds(1)))
+		Expect(session.followUps[0].Components).To(BeEmpty())
+		Expect(paginator.pages).To(BeEmpty())
+	})
+
+	It("sends nothing without pages", func() {
+		Expect(paginator.Send(session, command("user"), nil)).To(Succeed())
+		Expect(session.followUps).To(BeEmpty())
+	})
+
+	It("sends the first page with buttons", func() {
+		b := send(3)
+		Expect(session.followUps[0].Embeds).To(Equal(embeds(3)[:1]))
+		Expect(disabled(b)).To(Equal([]bool{true, true, false, false}))
+		for _, button := range b {
+			Expect(button.CustomID).To(HavePrefix("search;page;"))
+		}
+	})
+
+	It("keeps the pages within bounds", func() {
+		b := send(3)
+
+		Expect(click("user", b[1].CustomID)).To(Succeed())
+		Expect(last().Data.Embeds[0].Title).To(Equal("page 1"))
+
+		Expect(click("user", b[2].CustomID)).To(Succeed())
+		Expect(last().Type).To(Equal(discordgo.InteractionResponseUpdateMessage))
+		Expect(last().Data.Embeds[0].Title).To(Equal("page 2"))
+		Expect(disabled(buttons(last().Data.Components))).To(Equal([]bool{false, false, false, false}))
+
+		Expect(click("user", b[3].CustomID)).To(Succeed())
+		Expect(last().Data.Embeds[0].Title).To(Equal("page 3"))
+		Expect(disabled(buttons(last().Data.Components))).To(Equal([]bool{false, false, true, true}))
+
+		Expect(click("user", b[2].CustomID)).To(Succeed())
+		Expect(last().Data.Embeds[0].Title).To(Equal("page 3"))
+
+		Expect(click("user", b[0].CustomID)).To(Succeed())
+		Expect(last().Data.Embeds[0].Title).To(Equal("page 1"))
+	})
+
+	It("only lets the user who ran the command change pages", func() {
+		b := send(3)
+		Expect(click("other", b[2].CustomID)).To(Succeed())
+		Expect(last().Type).To(Equal(discordgo.InteractionResponseChannelMessageWithSource))
+		Expect(last().Data.Flags).To(Equal(discordgo.MessageFlagsEphemeral))
+
+		Expect(click("user", b[2].CustomID)).To(Succeed())
+		Expect(last().Data.Embeds[0].Title).To(Equal("page 2"))
+	})
+
+	It("removes the buttons of expired pages", func() {
+		paginator.ttl = -time.Minute
+		b := send(3)
+		Expect(click("user", b[2].CustomID)).To(Succeed())
+		Expect(last().Type).To(Equal(discordgo.InteractionResponseUpdateMessage))
+		Expect(last().Data.Components).To(BeEmpty())
+		Expect(last().Data.Embeds).To(Equal(embeds(3)[:1]))
+		Expect(paginator.pages).To(BeEmpty())
+	})
+
+	It("drops expired pages when sending new ones", func() {
+		paginator.ttl = -time.Minute
+		send(3)
+		paginator.ttl = time.Minute
+		session.followUps = nil
+		send(3)
+		Expect(paginator.pages).To(HaveLen(1))
+	})
+
+	It("removes the buttons of unknown pages", func() {
+		send(3)
+		Expect(click("user", "search;page;unknown;next")).To(Succeed())
+		Expect(last().Data.Components).To(BeEmpty())
+	})
+
+	It("rejects malformed paging buttons", func() {
+		send(3)
+		Expect(click("user", "search;page;next")).To(MatchError(`invalid paging button "search;page;next"`))
+		Expect(click("user", "search;page;id;next;extra")).To(HaveOccurred())
+	})
+
+	It("leaves the other interactions to the command", func() {
+		Expect(handler(session, command("user"))).To(Succeed())
+		Expect(click("user", "search;other")).To(Succeed())
+		Expect(handled).To(Equal(2))
+		Expect(session.responses).To(BeEmpty())
+	})
+
+	It("returns the errors of the command", func() {
+		handler = paginator.Wrap(func(s Session, i *discordgo.InteractionCreate) error {
+			return errors.New("failed")
+		})
+		Expect(handler(session, command("user"))).To(MatchError("failed"))
+	})
+})
@@ -110,7 +110,7 @@ func HandleInteractionCreate(commands map[string]*SlashCommand) func(*discordgo.
 			}
 		}()
 		switch ic.Type {
-		case discordgo.InteractionApplicationCommand:
+		case discordgo.InteractionApplicationCommand, discordgo.InteractionApplicationCommandAutocomplete:
 			if c, ok := commands[ic.ApplicationCommandData().Name]; ok {
 				err := c.handle(ss, ic)
 
@@ -145,3 +145,15 @@ func ErrorResponse(s Session, i *discordgo.InteractionCreate, err error) error {
 	})
 	return err
 }
+
+// UserID returns the user who created an interaction, in a guild or in a
+// direct message.
+func UserID(in *discordgo.InteractionCreate) string {
+	switch {
+	case in.Member != nil && in.Member.User != nil:
+		return in.Member.User.ID
+	case in.User != nil:
+		return in.User.ID
+	}
+	return ""
+}
@@ -0,0 +1,30 @@
+package discord
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestDiscord(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Discord Suite")
+}
+
+// fakeSession records the responses and followup messages sent.
+type fakeSession struct {
+	responses []*discordgo.InteractionResponse
+	followUps []*discordgo.WebhookParams
+}
+
+func (s *fakeSession) InteractionRespond(i *discordgo.Interaction, ir *discordgo.InteractionResponse, opts ...discordgo.RequestOption) error {
+	s.responses = append(s.responses, ir)
+	return nil
+}
+
+func (s *fakeSession) FollowupMessageCreate(i *discordgo.Interaction, waitResponse bool, params *discordgo.WebhookParams, opts ...discordgo.RequestOption) (*discordgo.Message, error) {
+	s.followUps = append(s.followUps, params)
+	return &discordgo.Message{Embeds: params.Embeds}, nil
+}